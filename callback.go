@@ -0,0 +1,135 @@
+package ipopt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDomain is returned by a callback when x lies outside the domain of the
+// function, for example log of a negative number. Ipopt then shortens the
+// step instead of aborting the solve.
+var ErrDomain = errors.New("point outside function domain")
+
+type EvalErrFunc func(x []float64, newX bool, objValue *float64) error
+type EvalGradErrFunc func(x []float64, newX bool, grad []float64) error
+type EvalGErrFunc func(x []float64, newX bool, m int, g []float64) error
+type EvalJacGErrFunc func(x []float64, newX bool, m int, jac [2][]int32, values []float64) error
+type EvalHErrFunc func(x []float64, newX bool, objFactor float64, m int, lambda []float64, newLambda bool, hess [2][]int32, values []float64) error
+
+// CallbackError is returned from Solve when a callback fails with an error
+// other than ErrDomain.
+type CallbackError struct {
+	Callback string
+	Err      error
+}
+
+func (e *CallbackError) Error() string {
+	return fmt.Sprintf("%s callback: %v", e.Callback, e.Err)
+}
+
+func (e *CallbackError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is returned from Solve when a callback panics. The panic is
+// recovered before it can unwind through Ipopt and the solve is stopped.
+// Stack holds the goroutine stack at the panic; it is not part of the
+// message.
+type PanicError struct {
+	Callback string
+	Value    any
+	Stack    []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s callback: %v", e.Callback, e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func newProblemCallback(opt *ProblemOptions) (*problemCallback, error) {
+	if opt.Eval != nil && opt.EvalErr != nil {
+		return nil, errors.New("Eval and EvalErr are mutually exclusive")
+	}
+	if opt.EvalGrad != nil && opt.EvalGradErr != nil {
+		return nil, errors.New("EvalGrad and EvalGradErr are mutually exclusive")
+	}
+	if opt.EvalG != nil && opt.EvalGErr != nil {
+		return nil, errors.New("EvalG and EvalGErr are mutually exclusive")
+	}
+	if opt.EvalJacG != nil && opt.EvalJacGErr != nil {
+		return nil, errors.New("EvalJacG and EvalJacGErr are mutually exclusive")
+	}
+	if opt.EvalH != nil && opt.EvalHErr != nil {
+		return nil, errors.New("EvalH and EvalHErr are mutually exclusive")
+	}
+
+	cb := &problemCallback{
+		eval:     opt.EvalErr,
+		evalGrad: opt.EvalGradErr,
+		evalG:    opt.EvalGErr,
+		evalJacG: opt.EvalJacGErr,
+		evalH:    opt.EvalHErr,
+
+		intermediate: opt.Intermediate,
+		logger:       opt.Logger,
+	}
+	if opt.Eval != nil {
+		cb.eval = opt.Eval.withErr()
+	}
+	if opt.EvalGrad != nil {
+		cb.evalGrad = opt.EvalGrad.withErr()
+	}
+	if opt.EvalG != nil {
+		cb.evalG = opt.EvalG.withErr()
+	}
+	if opt.EvalJacG != nil {
+		cb.evalJacG = opt.EvalJacG.withErr()
+	}
+	if opt.EvalH != nil {
+		cb.evalH = opt.EvalH.withErr()
+	}
+	return cb, nil
+}
+
+// domainErr maps the false return of the bool callbacks to ErrDomain.
+func domainErr(ok bool) error {
+	if !ok {
+		return ErrDomain
+	}
+	return nil
+}
+
+func (f EvalFunc) withErr() EvalErrFunc {
+	return func(x []float64, newX bool, objValue *float64) error {
+		return domainErr(f(x, newX, objValue))
+	}
+}
+
+func (f EvalGradFunc) withErr() EvalGradErrFunc {
+	return func(x []float64, newX bool, grad []float64) error {
+		return domainErr(f(x, newX, grad))
+	}
+}
+
+func (f EvalGFunc) withErr() EvalGErrFunc {
+	return func(x []float64, newX bool, m int, g []float64) error {
+		return domainErr(f(x, newX, m, g))
+	}
+}
+
+func (f EvalJacGFunc) withErr() EvalJacGErrFunc {
+	return func(x []float64, newX bool, m int, jac [2][]int32, values []float64) error {
+		return domainErr(f(x, newX, m, jac, values))
+	}
+}
+
+func (f EvalHFunc) withErr() EvalHErrFunc {
+	return func(x []float64, newX bool, objFactor float64, m int, lambda []float64, newLambda bool, hess [2][]int32, values []float64) error {
+		return domainErr(f(x, newX, objFactor, m, lambda, newLambda, hess, values))
+	}
+}
@@ -17,109 +17,240 @@ package ipopt
 #cgo darwin,arm64 LDFLAGS: -L /usr/lib -lc++ -L ./lib/darwin_arm  -lipopt  -lma27 -lmetis -lcipopt -lm  -framework Accelerate  -lgfortran
 #cgo windows LDFLAGS: -L ./lib/windows -lipopt -llapack -lblas -lma27 -lmetis -lcipopt -fPIC
 
-extern bool evalFunc(int n, float *x, bool new_x, float *obj_value,
-                          void *user_data);
-extern bool evalGradFunc(int n, float *x, bool new_x, float *grad_f,
-                               void *user_data);
-extern bool evalGFunc(int n, float *x, bool new_x, int m, float *g,
-                          void *user_data);
-extern bool evalJacGFunc(int n, float *x, bool new_x, int m, int nele_jac,
-                              int *iRow, int *jCol, float *values,
-                              void *user_data);
-extern bool evalHFunc(int n, float *x, bool new_x, float obj_factor, int m,
-                          float *lambda, bool new_lambda, int nele_hess,
-                          int *iRow, int *jCol, float *values, void *user_data);
-
-bool ipopt_eval_func_go(int n, float *x, bool new_x, float *obj_value,
+extern bool evalFunc(int n, double *x, bool new_x, double *obj_value,
+                          uintptr_t user_data);
+extern bool evalGradFunc(int n, double *x, bool new_x, double *grad_f,
+                               uintptr_t user_data);
+extern bool evalGFunc(int n, double *x, bool new_x, int m, double *g,
+                          uintptr_t user_data);
+extern bool evalJacGFunc(int n, double *x, bool new_x, int m, int nele_jac,
+                              int *iRow, int *jCol, double *values,
+                              uintptr_t user_data);
+extern bool evalHFunc(int n, double *x, bool new_x, double obj_factor, int m,
+                          double *lambda, bool new_lambda, int nele_hess,
+                          int *iRow, int *jCol, double *values, uintptr_t user_data);
+extern bool intermediateFunc(int alg_mod, int iter_count, double obj_value,
+                             double inf_pr, double inf_du, double mu,
+                             double d_norm, double regularization_size,
+                             double alpha_du, double alpha_pr, int ls_trials,
+                             uintptr_t user_data);
+
+bool ipopt_eval_func_go(int n, double *x, bool new_x, double *obj_value,
                           void *user_data) {
-    return evalFunc(n, x, new_x, obj_value, user_data);
+    return evalFunc(n, x, new_x, obj_value, (uintptr_t)user_data);
 }
 
-bool ipopt_eval_grad_func_go(int n, float *x, bool new_x, float *grad_f,
+bool ipopt_eval_grad_func_go(int n, double *x, bool new_x, double *grad_f,
                                void *user_data) {
-    return evalGradFunc(n, x, new_x, grad_f, user_data);
+    return evalGradFunc(n, x, new_x, grad_f, (uintptr_t)user_data);
 }
 
-bool ipopt_eval_g_func_go(int n, float *x, bool new_x, int m, float *g,
+bool ipopt_eval_g_func_go(int n, double *x, bool new_x, int m, double *g,
                           void *user_data) {
-    return evalGFunc(n, x, new_x, m, g, user_data);
+    return evalGFunc(n, x, new_x, m, g, (uintptr_t)user_data);
 }
 
-bool ipopt_eval_jac_g_func_go(int n, float *x, bool new_x, int m, int nele_jac,
-                              int *iRow, int *jCol, float *values,
+bool ipopt_eval_jac_g_func_go(int n, double *x, bool new_x, int m, int nele_jac,
+                              int *iRow, int *jCol, double *values,
                               void *user_data) {
-    return evalJacGFunc(n, x, new_x, m, nele_jac, iRow, jCol, values, user_data);
+    return evalJacGFunc(n, x, new_x, m, nele_jac, iRow, jCol, values, (uintptr_t)user_data);
 }
 
-bool ipopt_eval_h_func_go(int n, float *x, bool new_x, float obj_factor, int m,
-                          float *lambda, bool new_lambda, int nele_hess,
-                          int *iRow, int *jCol, float *values, void *user_data) {
-    return evalHFunc(n, x, new_x, obj_factor, m, lambda, new_lambda, nele_hess, iRow, jCol, values, user_data);
+bool ipopt_eval_h_func_go(int n, double *x, bool new_x, double obj_factor, int m,
+                          double *lambda, bool new_lambda, int nele_hess,
+                          int *iRow, int *jCol, double *values, void *user_data) {
+    return evalHFunc(n, x, new_x, obj_factor, m, lambda, new_lambda, nele_hess, iRow, jCol, values, (uintptr_t)user_data);
+}
+
+bool ipopt_intermediate_func_go(int alg_mod, int iter_count, double obj_value,
+                                double inf_pr, double inf_du, double mu,
+                                double d_norm, double regularization_size,
+                                double alpha_du, double alpha_pr, int ls_trials,
+                                void *user_data) {
+    return intermediateFunc(alg_mod, iter_count, obj_value, inf_pr, inf_du, mu,
+                            d_norm, regularization_size, alpha_du, alpha_pr,
+                            ls_trials, (uintptr_t)user_data);
 }
 */
 import "C"
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"runtime/cgo"
+	"sync"
+	"time"
 	"unsafe"
 )
 
+// ErrProblemClosed is returned by methods of a Problem after Close.
+var ErrProblemClosed = errors.New("problem is closed")
+
+const (
+	// defaultMaxWallTime is Ipopt's default for the max_wall_time option.
+	defaultMaxWallTime = 1e20
+	// minWallTime is used for deadlines that already passed, since
+	// max_wall_time must be positive.
+	minWallTime = 1e-9
+)
+
+// AlgorithmMode reports which phase of the algorithm produced an iteration.
+type AlgorithmMode int
+
 const (
-	IPOPT_SOLVE_SUCCEEDED                    = int(C.solve_succeeded)
-	IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL         = int(C.solved_to_acceptable_level)
-	IPOPT_INFEASIBLE_PROBLEM_DETECTED        = int(C.infeasible_problem_detected)
-	IPOPT_SEARCH_DIRECTION_BECOMES_TOO_SMALL = int(C.search_direction_becomes_too_small)
-	IPOPT_DIVERGING_ITERATES                 = int(C.diverging_iterates)
-	IPOPT_USER_REQUESTED_STOP                = int(C.user_requested_stop)
-	IPOPT_FEASIBLE_POINT_FOUND               = int(C.feasible_point_found)
-	IPOPT_MAXIMUM_ITERATIONS_EXCEEDED        = int(C.maximum_iterations_exceeded)
-	IPOPT_RESTORATION_FAILED                 = int(C.restoration_failed)
-	IPOPT_ERROR_IN_STEP_COMPUTATION          = int(C.error_in_step_computation)
-	IPOPT_MAXIMUM_CPUTIME_EXCEEDED           = int(C.maximum_cputime_exceeded)
-	IPOPT_MAXIMUM_WALLTIME_EXCEEDED          = int(C.maximum_walltime_exceeded)
-	IPOPT_NOT_ENOUGH_DEGREES_OF_FREEDOM      = int(C.not_enough_degrees_of_freedom)
-	IPOPT_INVALID_PROBLEM_DEFINITION         = int(C.invalid_problem_definition)
-	IPOPT_INVALID_OPTION                     = int(C.invalid_option)
-	IPOPT_INVALID_NUMBER_DETECTED            = int(C.invalid_number_detected)
-	IPOPT_UNRECOVERABLE_EXCEPTION            = int(C.unrecoverable_exception)
-	IPOPT_NON_IPOPT_EXCEPTION_THROWN         = int(C.non_ipopt_exception_thrown)
-	IPOPT_INSUFFICIENT_MEMORY                = int(C.insufficient_memory)
-	IPOPT_INTERNAL_ERROR                     = int(C.internal_error)
+	RegularMode          AlgorithmMode = 0
+	RestorationPhaseMode AlgorithmMode = 1
 )
 
+func (m AlgorithmMode) String() string {
+	if m == RestorationPhaseMode {
+		return "restoration"
+	}
+	return "regular"
+}
+
+// Iteration holds the per-iteration values Ipopt reports to the
+// intermediate callback. Inside the callback, Iterate and Violations give
+// the full iterate.
+type Iteration struct {
+	Mode               AlgorithmMode
+	Iter               int
+	ObjValue           float64
+	InfPr              float64
+	InfDu              float64
+	Mu                 float64
+	DNorm              float64
+	RegularizationSize float64
+	AlphaDu            float64
+	AlphaPr            float64
+	LsTrials           int
+
+	state *iterationState
+}
+
 type EvalFunc func(x []float64, newX bool, objValue *float64) bool
 type EvalGradFunc func(x []float64, newX bool, grad []float64) bool
 type EvalGFunc func(x []float64, newX bool, m int, g []float64) bool
 type EvalJacGFunc func(x []float64, newX bool, m int, jac [2][]int32, values []float64) bool
 type EvalHFunc func(x []float64, newX bool, objFactor float64, m int, lambda []float64, newLambda bool, hess [2][]int32, values []float64) bool
 
+// IntermediateFunc is called by Ipopt once per iteration. Returning false
+// stops the solve with IPOPT_USER_REQUESTED_STOP.
+type IntermediateFunc func(it Iteration) bool
+
 type ProblemOptions struct {
 	Variables              [2][]float64
 	Constraints            [2][]float64
 	NumConstraintJacobian  int
 	NumHessianOfLagrangian int
-	Eval                   EvalFunc
-	EvalGrad               EvalGradFunc
-	EvalG                  EvalGFunc
-	EvalJacG               EvalJacGFunc
-	EvalH                  EvalHFunc
+	// JacobianStructure and HessianStructure optionally declare the row and
+	// column indices of the nonzeros. When set, the library answers Ipopt's
+	// structure queries itself and EvalJacG and EvalH are only called for
+	// values. The Num* counts may then be left zero.
+	JacobianStructure [2][]int32
+	HessianStructure  [2][]int32
+	Eval              EvalFunc
+	EvalGrad          EvalGradFunc
+	EvalG             EvalGFunc
+	EvalJacG          EvalJacGFunc
+	// EvalH may be left nil together with EvalHErr; Ipopt then uses a
+	// limited-memory Hessian approximation and NumHessianOfLagrangian is
+	// ignored.
+	EvalH        EvalHFunc
+	Intermediate IntermediateFunc
+
+	// Error-returning alternatives to the callbacks above. At most one of
+	// each pair may be set.
+	EvalErr     EvalErrFunc
+	EvalGradErr EvalGradErrFunc
+	EvalGErr    EvalGErrFunc
+	EvalJacGErr EvalJacGErrFunc
+	EvalHErr    EvalHErrFunc
+
+	// NLP defines the problem through an interface instead of the callback
+	// fields, which must then be left nil.
+	NLP NLP
+
+	// Logger receives structured records of solver events, see SetLogger.
+	Logger *slog.Logger
 }
 
 type problemCallback struct {
-	eval     EvalFunc
-	evalGrad EvalGradFunc
-	evalG    EvalGFunc
-	evalJacG EvalJacGFunc
-	evalH    EvalHFunc
+	eval     EvalErrFunc
+	evalGrad EvalGradErrFunc
+	evalG    EvalGErrFunc
+	evalJacG EvalJacGErrFunc
+	evalH    EvalHErrFunc
+
+	intermediate IntermediateFunc
+	output       io.Writer
+	logger       *slog.Logger
+	observers    []iterationObserver
+	mode         AlgorithmMode
+	stats        Stats
+
+	jacStructure  [2][]int32
+	hessStructure [2][]int32
+
+	ctx  context.Context
+	iter int
+	err  error
+
+	// inner is the problem the callbacks belong to, handed to the
+	// intermediate callback through Iteration.
+	inner *innerProblem
 }
 
+// Problem is an Ipopt problem instance. Its methods may be called from
+// several goroutines: calls on the same Problem are serialized, while
+// distinct Problems share no state and can be solved in parallel.
+// Callbacks run on the goroutine that called Solve and must not call
+// methods of their own Problem; the intermediate callback reads the solver
+// state through Iteration.Iterate and Iteration.Violations instead.
 type Problem struct {
 	inner *innerProblem
 	opt   *ProblemOptions
 }
 
 type innerProblem struct {
+	mu      sync.Mutex
 	problem *C.struct__ipopt_problem_t
 	cb      *problemCallback
+	handle  cgo.Handle
+	n       int
+	m       int
+	nnzj    int
+	nnzh    int
+	options map[string]any
+
+	// structureChecked is set once the structures returned by the
+	// callbacks have been validated.
+	structureChecked bool
+}
+
+// Iterate is a snapshot of the primal and dual variables of the iteration
+// in progress.
+type Iterate struct {
+	X      []float64
+	ZL     []float64
+	ZU     []float64
+	G      []float64
+	Lambda []float64
+}
+
+// Violations holds the per-variable and per-constraint violations of the
+// iteration in progress.
+type Violations struct {
+	XLViolation         []float64
+	XUViolation         []float64
+	ComplXL             []float64
+	ComplXU             []float64
+	GradLagX            []float64
+	ConstraintViolation []float64
+	ComplG              []float64
 }
 
 func NewProblem(opt ProblemOptions) (*Problem, error) {
@@ -133,11 +264,87 @@ func NewProblem(opt ProblemOptions) (*Problem, error) {
 		return nil, errors.New("constraints len mast eq")
 	}
 
+	if len(opt.Variables[0]) == 0 {
+		return nil, errors.New("problem must have at least one variable")
+	}
+
+	if opt.NumConstraintJacobian < 0 || opt.NumHessianOfLagrangian < 0 {
+		return nil, errors.New("number of nonzeros must not be negative")
+	}
+
+	if opt.NLP != nil {
+		if err := opt.applyNLP(); err != nil {
+			return nil, err
+		}
+	}
+
+	cb, err := newProblemCallback(&opt)
+	if err != nil {
+		return nil, err
+	}
+	if cb.eval == nil {
+		return nil, errors.New("Eval or EvalErr is required")
+	}
+	if cb.evalGrad == nil {
+		return nil, errors.New("EvalGrad or EvalGradErr is required")
+	}
+
+	if opt.JacobianStructure[0] != nil || opt.JacobianStructure[1] != nil {
+		nnzj, err := structureSize("jacobian", opt.JacobianStructure, opt.NumConstraintJacobian)
+		if err != nil {
+			return nil, err
+		}
+		opt.NumConstraintJacobian = nnzj
+		cb.jacStructure = opt.JacobianStructure
+
+		err = checkStructure("jacobian", opt.JacobianStructure, len(opt.Constraints[0]), len(opt.Variables[0]), false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opt.HessianStructure[0] != nil || opt.HessianStructure[1] != nil {
+		if cb.evalH == nil {
+			return nil, errors.New("HessianStructure requires EvalH")
+		}
+		nnzh, err := structureSize("hessian", opt.HessianStructure, opt.NumHessianOfLagrangian)
+		if err != nil {
+			return nil, err
+		}
+		opt.NumHessianOfLagrangian = nnzh
+		cb.hessStructure = opt.HessianStructure
+
+		err = checkStructure("hessian", opt.HessianStructure, len(opt.Variables[0]), len(opt.Variables[0]), true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(opt.Constraints[0]) == 0 {
+		if opt.NumConstraintJacobian != 0 {
+			return nil, errors.New("NumConstraintJacobian must be 0 without constraints")
+		}
+	} else {
+		if opt.NumConstraintJacobian == 0 {
+			return nil, errors.New("NumConstraintJacobian must be positive with constraints")
+		}
+		if cb.evalG == nil || cb.evalJacG == nil {
+			return nil, errors.New("constraints require EvalG and EvalJacG")
+		}
+	}
+
+	// Without a Hessian callback Ipopt approximates the Hessian and never
+	// asks for its structure.
+	if cb.evalH == nil {
+		opt.NumHessianOfLagrangian = 0
+	}
+
 	eval_f := (C.eval_f_cb)(unsafe.Pointer(C.ipopt_eval_func_go))
 	eval_grad_f := (C.eval_grad_f_cb)(unsafe.Pointer(C.ipopt_eval_grad_func_go))
 	eval_g := (C.eval_g_cb)(unsafe.Pointer(C.ipopt_eval_g_func_go))
 	eval_jac_g := (C.eval_jac_g_cb)(unsafe.Pointer(C.ipopt_eval_jac_g_func_go))
 	eval_h := (C.eval_h_cb)(unsafe.Pointer(C.ipopt_eval_h_func_go))
+	intermediate := (C.intermediate_cb)(unsafe.Pointer(C.ipopt_intermediate_func_go))
 
 	xL := toCFloatArray(opt.Variables[0])
 	xU := toCFloatArray(opt.Variables[1])
@@ -147,74 +354,334 @@ func NewProblem(opt ProblemOptions) (*Problem, error) {
 
 	n := len(opt.Variables[0])
 
-	problem = C.ipopt_problem_create(C.int(n), &xL[0], &xU[0],
-		C.int(len(opt.Constraints[0])), &gl[0], &gu[0],
+	problem = C.ipopt_problem_create(C.int(n), toCFloatPtr(xL), toCFloatPtr(xU),
+		C.int(len(opt.Constraints[0])), toCFloatPtr(gl), toCFloatPtr(gu),
 		C.int(opt.NumConstraintJacobian), C.int(opt.NumHessianOfLagrangian),
 		eval_f, eval_grad_f, eval_g, eval_jac_g, eval_h)
-
-	cb := &problemCallback{
-		eval:     opt.Eval,
-		evalGrad: opt.EvalGrad,
-		evalG:    opt.EvalG,
-		evalJacG: opt.EvalJacG,
-		evalH:    opt.EvalH,
+	if problem == nil {
+		return nil, ErrInvalidProblemDefinition
 	}
+	C.ipopt_problem_set_intermediate_callback(problem, intermediate)
 
 	g := &Problem{inner: &innerProblem{
-		problem: problem, cb: cb,
+		problem: problem, cb: cb, handle: cgo.NewHandle(cb),
+		n: n, m: len(opt.Constraints[0]),
+		nnzj: opt.NumConstraintJacobian, nnzh: opt.NumHessianOfLagrangian,
+		options: map[string]any{},
 	}, opt: &opt}
+	cb.inner = g.inner
+	runtime.SetFinalizer(g, (*Problem).finalize)
+
+	if cb.evalH == nil {
+		if err := g.inner.addStrOption("hessian_approximation", "limited-memory"); err != nil {
+			g.Close()
+			return nil, err
+		}
+	}
+
+	if opt.NLP != nil {
+		if err := g.inner.configureNLP(opt.NLP); err != nil {
+			g.Close()
+			return nil, err
+		}
+	}
 
 	return g, nil
 }
 
-func (p *Problem) AddStrOption(param string, value string) {
-	cparam := C.CString(param)
-	cvalue := C.CString(value)
-	C.ipopt_problem_add_str_option(p.inner.problem, cparam, cvalue)
-	C.free(unsafe.Pointer(cparam))
-	C.free(unsafe.Pointer(cvalue))
+// Close frees the underlying Ipopt problem. Any later call on p returns
+// ErrProblemClosed. Close is safe to call more than once.
+//
+// A finalizer frees problems that become unreachable without Close, but the
+// callbacks stay registered until then: a Problem referenced from its own
+// callbacks never becomes unreachable and is only released by Close. The
+// intermediate callback gets the solver state from its Iteration and does
+// not need such a reference.
+func (p *Problem) Close() error {
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	p.inner.free()
+	runtime.SetFinalizer(p, nil)
+	return nil
 }
 
-func (p *Problem) AddIntOption(param string, value int) {
-	cparam := C.CString(param)
-	C.ipopt_problem_add_int_option(p.inner.problem, cparam, C.int(value))
-	C.free(unsafe.Pointer(cparam))
+func (p *Problem) finalize() {
+	p.inner.free()
 }
 
-func (p *Problem) AddNumOption(param string, value float32) {
-	cparam := C.CString(param)
-	C.ipopt_problem_add_num_option(p.inner.problem, cparam, C.float(value))
-	C.free(unsafe.Pointer(cparam))
+// SetLogger makes the problem emit slog records for solve start and
+// finish at Info level (Warn for unsuccessful solves), entry into and exit
+// from the restoration phase at Info level, and every iteration at Debug
+// level. A nil l disables logging.
+func (p *Problem) SetLogger(l *slog.Logger) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	p.inner.cb.logger = l
+	return nil
+}
+
+// SetIntermediateCallback registers fn to be called once per iteration,
+// replacing any callback given in ProblemOptions. A nil fn removes it.
+func (p *Problem) SetIntermediateCallback(fn IntermediateFunc) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	p.inner.cb.intermediate = fn
+	return nil
+}
+
+// AddStrOption sets a string option. An *OptionError is returned when Ipopt
+// rejects the name or the value.
+func (p *Problem) AddStrOption(param string, value string) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	return p.inner.addStrOption(param, value)
+}
+
+// AddIntOption sets an integer option, see AddStrOption.
+func (p *Problem) AddIntOption(param string, value int) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	return p.inner.addIntOption(param, value)
+}
+
+// AddNumOption sets a numeric option, see AddStrOption.
+func (p *Problem) AddNumOption(param string, value float64) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	return p.inner.addNumOption(param, value)
+}
+
+// SetScaling sets the scaling factors of the objective, the variables and the
+// constraints, and makes Ipopt use them. xScale and gScale may be nil to
+// leave the variables or the constraints unscaled; otherwise they must have
+// one entry per variable or constraint.
+func (p *Problem) SetScaling(objScale float64, xScale []float64, gScale []float64) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	return p.inner.setScaling(objScale, xScale, gScale)
+}
+
+// Iterate returns the primal and dual variables of it. It is only
+// available while the intermediate callback that received it runs, on that
+// callback's goroutine; scaled selects the internal, scaled representation
+// of the problem.
+func (it Iteration) Iterate(scaled bool) (*Iterate, error) {
+	p := it.solver()
+	if p == nil {
+		return nil, errors.New("iterate is only available in the intermediate callback")
+	}
+
+	n, m := p.n, p.m
+
+	cx := make([]C.double, n)
+	czL := make([]C.double, n)
+	czU := make([]C.double, n)
+	cg := make([]C.double, m)
+	clambda := make([]C.double, m)
+
+	ok := C.ipopt_problem_get_current_iterate(p.problem, C.bool(scaled),
+		C.int(n), toCFloatPtr(cx), toCFloatPtr(czL), toCFloatPtr(czU),
+		C.int(m), toCFloatPtr(cg), toCFloatPtr(clambda))
+	if !ok {
+		return nil, errors.New("iterate is only available in the intermediate callback")
+	}
+
+	return &Iterate{
+		X:      toGoFloat64Array(cx),
+		ZL:     toGoFloat64Array(czL),
+		ZU:     toGoFloat64Array(czU),
+		G:      toGoFloat64Array(cg),
+		Lambda: toGoFloat64Array(clambda),
+	}, nil
+}
+
+// Violations returns the violations of the iterate of it. Like Iterate it
+// only works inside the intermediate callback.
+func (it Iteration) Violations(scaled bool) (*Violations, error) {
+	p := it.solver()
+	if p == nil {
+		return nil, errors.New("violations are only available in the intermediate callback")
+	}
+
+	n, m := p.n, p.m
+
+	cxL := make([]C.double, n)
+	cxU := make([]C.double, n)
+	ccomplXL := make([]C.double, n)
+	ccomplXU := make([]C.double, n)
+	cgradLagX := make([]C.double, n)
+	cviol := make([]C.double, m)
+	ccomplG := make([]C.double, m)
+
+	ok := C.ipopt_problem_get_current_violations(p.problem, C.bool(scaled),
+		C.int(n), toCFloatPtr(cxL), toCFloatPtr(cxU), toCFloatPtr(ccomplXL),
+		toCFloatPtr(ccomplXU), toCFloatPtr(cgradLagX),
+		C.int(m), toCFloatPtr(cviol), toCFloatPtr(ccomplG))
+	if !ok {
+		return nil, errors.New("violations are only available in the intermediate callback")
+	}
+
+	return &Violations{
+		XLViolation:         toGoFloat64Array(cxL),
+		XUViolation:         toGoFloat64Array(cxU),
+		ComplXL:             toGoFloat64Array(ccomplXL),
+		ComplXU:             toGoFloat64Array(ccomplXU),
+		GradLagX:            toGoFloat64Array(cgradLagX),
+		ConstraintViolation: toGoFloat64Array(cviol),
+		ComplG:              toGoFloat64Array(ccomplG),
+	}, nil
+}
+
+// iterationState ties an Iteration to the problem being solved while the
+// intermediate callback that received it runs. It is cleared when the
+// callback returns, so Iterations kept afterwards no longer reach Ipopt.
+type iterationState struct {
+	p *innerProblem
+}
+
+func (it Iteration) solver() *innerProblem {
+	if it.state == nil {
+		return nil
+	}
+	return it.state.p
 }
 
 func (p *Problem) Solve(x []float64, g []float64, objVal []float64, multG []float64, multxL []float64, multxU []float64, needFreeProblem bool) ([]float64, error) {
-	cX := toCFloatArray(x)
-	cg := toCFloatArray(g)
+	return p.SolveContext(context.Background(), x, g, objVal, multG, multxL, multxU, needFreeProblem)
+}
 
-	ccX := (*C.double)(&cX[0])
+// SolveContext is like Solve but stops Ipopt at the next iteration once ctx
+// is cancelled, returning ctx.Err() with the last iterate written to x. A
+// deadline on ctx is also applied as Ipopt's max_wall_time.
+func (p *Problem) SolveContext(ctx context.Context, x []float64, g []float64, objVal []float64, multG []float64, multxL []float64, multxU []float64, needFreeProblem bool) ([]float64, error) {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
 
-	var ccg *C.double
-	if len(cg) > 0 {
-		ccg = (*C.double)(&cg[0])
-	} else {
-		ccg = nil
+	if p.inner.closed() {
+		return nil, ErrProblemClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := p.inner.checkLengths(x, g, objVal, multG, multxL, multxU); err != nil {
+		return nil, err
+	}
+	if err := p.inner.checkCallbackStructures(); err != nil {
+		return nil, err
 	}
 
+	ret, err := p.solve(ctx, x, g, objVal, multG, multxL, multxU)
+
+	if needFreeProblem {
+		p.inner.free()
+		runtime.SetFinalizer(p, nil)
+	}
+
+	if err != nil {
+		return objVal, err
+	}
+	if ret == IPOPT_SOLVE_SUCCEEDED {
+		return objVal, nil
+	}
+	return objVal, ret.Err()
+}
+
+// solve runs Ipopt from the starting point x. All slices are updated in
+// place with the final values. A non-nil error means the solve was
+// interrupted through ctx or by a failing callback.
+func (p *Problem) solve(ctx context.Context, x []float64, g []float64, objVal []float64, multG []float64, multxL []float64, multxU []float64) (ret Status, err error) {
+	if p.inner.cb.logger != nil {
+		start := time.Now()
+		p.inner.logStart(ctx)
+		defer func() {
+			p.inner.logFinish(ctx, ret, objVal[0], time.Since(start), err)
+		}()
+	}
+
+	limited := false
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline).Seconds()
+		if remaining <= 0 {
+			remaining = minWallTime
+		}
+		limited = p.inner.limitWallTime(remaining)
+	}
+
+	if ctx.Done() != nil {
+		p.inner.cb.ctx = ctx
+	}
+	p.inner.cb.iter = 0
+	p.inner.cb.mode = RegularMode
+	p.inner.cb.stats = Stats{}
+	p.inner.cb.err = nil
+
+	cX := toCFloatArray(x)
+	cg := toCFloatArray(g)
+
 	cobjVal := toCFloatArray(objVal)
-	cmultG := toCFloatArray(multG)
 
+	// With warm_start_init_point Ipopt reads all multipliers and fails on
+	// NULL, even for m = 0, so omitted ones are backed by scratch buffers.
+	cmultG := toCFloatArray(multG)
+	if len(cmultG) == 0 {
+		cmultG = make([]C.double, max(p.inner.m, 1))
+	}
 	cmultxL := toCFloatArray(multxL)
+	if len(cmultxL) == 0 {
+		cmultxL = make([]C.double, p.inner.n)
+	}
 	cmultxU := toCFloatArray(multxU)
+	if len(cmultxU) == 0 {
+		cmultxU = make([]C.double, p.inner.n)
+	}
 
-	userData := (*C.char)(unsafe.Pointer(p.inner.cb))
+	userData := C.uintptr_t(p.inner.handle)
 
-	ret := (int)(C.ipopt_problem_solve(p.inner.problem,
-		ccX,
-		ccg,
-		(*C.double)(&cobjVal[0]),
-		(*C.double)(&cmultG[0]),
-		(*C.double)(&cmultxL[0]),
-		(*C.double)(&cmultxU[0]),
+	ret = Status(C.ipopt_problem_solve(p.inner.problem,
+		toCFloatPtr(cX),
+		toCFloatPtr(cg),
+		toCFloatPtr(cobjVal),
+		toCFloatPtr(cmultG),
+		toCFloatPtr(cmultxL),
+		toCFloatPtr(cmultxU),
 		userData))
 
 	toCopyFloatArray(cmultG, multG)
@@ -222,65 +689,144 @@ func (p *Problem) Solve(x []float64, g []float64, objVal []float64, multG []floa
 	toCopyFloatArray(cmultxU, multxU)
 	toCopyFloatArray(cobjVal, objVal)
 	toCopyFloatArray(cX, x)
+	toCopyFloatArray(cg, g)
 
-	if needFreeProblem {
-		p.inner.free()
+	p.inner.cb.ctx = nil
+	if limited {
+		p.inner.restoreWallTime()
 	}
 
-	if ret == IPOPT_SOLVE_SUCCEEDED {
-		return objVal, nil
+	if err := p.inner.cb.err; err != nil {
+		p.inner.cb.err = nil
+		return ret, err
+	}
+	// Only a stop caused by ctx is reported as its error; a cancel that
+	// arrives after the last iteration leaves the result alone.
+	if ret == IPOPT_USER_REQUESTED_STOP {
+		if err := ctx.Err(); err != nil {
+			return ret, err
+		}
+	}
+	if limited && ret == IPOPT_MAXIMUM_WALLTIME_EXCEEDED {
+		return ret, context.DeadlineExceeded
 	}
-	return nil, resultStatus(ret)
-}
-
-func resultStatus(code int) error {
-	var s string
-	switch code {
-	case IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL:
-		s = "Solved To Acceptable Level"
-	case IPOPT_INFEASIBLE_PROBLEM_DETECTED:
-		s = "Infeasible Problem Detected"
-	case IPOPT_SEARCH_DIRECTION_BECOMES_TOO_SMALL:
-		s = "Search Direction Becomes Too Small"
-	case IPOPT_DIVERGING_ITERATES:
-		s = "Diverging Iterates"
-	case IPOPT_USER_REQUESTED_STOP:
-		s = "User Requested Stop"
-	case IPOPT_FEASIBLE_POINT_FOUND:
-		s = "Feasible Point Found"
-	case IPOPT_MAXIMUM_ITERATIONS_EXCEEDED:
-		s = "Maximum Iterations Exceeded"
-	case IPOPT_RESTORATION_FAILED:
-		s = "Restoration Failed"
-	case IPOPT_ERROR_IN_STEP_COMPUTATION:
-		s = "Error In Step Computation"
-	case IPOPT_MAXIMUM_CPUTIME_EXCEEDED:
-		s = "Maximum CpuTime Exceeded"
-	case IPOPT_MAXIMUM_WALLTIME_EXCEEDED:
-		s = "Maximum WallTime Exceeded"
-	case IPOPT_NOT_ENOUGH_DEGREES_OF_FREEDOM:
-		s = "Not Enough Degrees Of Freedom"
-	case IPOPT_INVALID_PROBLEM_DEFINITION:
-		s = "Invalid Problem Definition"
-	case IPOPT_INVALID_OPTION:
-		s = "Invalid Option"
-	case IPOPT_INVALID_NUMBER_DETECTED:
-		s = "Invalid Number Detected"
-	case IPOPT_UNRECOVERABLE_EXCEPTION:
-		s = "Unrecoverable Exception"
-	case IPOPT_NON_IPOPT_EXCEPTION_THROWN:
-		s = "NonIpopt Exception Thrown"
-	case IPOPT_INSUFFICIENT_MEMORY:
-		s = "Insufficient Memory"
-	case IPOPT_INTERNAL_ERROR:
-		s = "Internal Error"
-	}
-	return errors.New(s)
+	return ret, nil
+}
+
+// checkLengths validates the slices passed to Solve against the problem
+// dimensions. The outputs g, multG, multxL and multxU may be left empty.
+func (p *innerProblem) checkLengths(x, g, objVal, multG, multxL, multxU []float64) error {
+	if len(x) != p.n {
+		return fmt.Errorf("x has %d elements, want %d", len(x), p.n)
+	}
+	if len(objVal) == 0 {
+		return errors.New("objVal must have at least one element")
+	}
+	for _, s := range []struct {
+		name string
+		v    []float64
+		want int
+	}{
+		{"g", g, p.m},
+		{"multG", multG, p.m},
+		{"multxL", multxL, p.n},
+		{"multxU", multxU, p.n},
+	} {
+		if len(s.v) != 0 && len(s.v) != s.want {
+			return fmt.Errorf("%s has %d elements, want %d", s.name, len(s.v), s.want)
+		}
+	}
+	return nil
+}
+
+// limitWallTime caps max_wall_time at seconds for the next solve. It reports
+// whether the cap was applied, i.e. whether it is tighter than the limit the
+// user configured.
+func (p *innerProblem) limitWallTime(seconds float64) bool {
+	if v, ok := p.options["max_wall_time"].(float64); ok && v <= seconds {
+		return false
+	}
+	p.setNumOption("max_wall_time", seconds)
+	return true
+}
+
+func (p *innerProblem) restoreWallTime() {
+	if v, ok := p.options["max_wall_time"].(float64); ok {
+		p.setNumOption("max_wall_time", v)
+		return
+	}
+	p.setNumOption("max_wall_time", defaultMaxWallTime)
+}
+
+func (p *innerProblem) addStrOption(param string, value string) error {
+	cparam := C.CString(param)
+	cvalue := C.CString(value)
+	ok := C.ipopt_problem_add_str_option(p.problem, cparam, cvalue)
+	C.free(unsafe.Pointer(cparam))
+	C.free(unsafe.Pointer(cvalue))
+	if !ok {
+		return newOptionError(param, value, OptionString)
+	}
+	p.options[param] = value
+	return nil
+}
+
+func (p *innerProblem) addIntOption(param string, value int) error {
+	cparam := C.CString(param)
+	ok := C.ipopt_problem_add_int_option(p.problem, cparam, C.int(value))
+	C.free(unsafe.Pointer(cparam))
+	if !ok {
+		return newOptionError(param, value, OptionInteger)
+	}
+	p.options[param] = value
+	return nil
+}
+
+func (p *innerProblem) addNumOption(param string, value float64) error {
+	if !p.setNumOption(param, value) {
+		return newOptionError(param, value, OptionNumber)
+	}
+	p.options[param] = value
+	return nil
+}
+
+// setNumOption sets a numeric option without recording it as configured by
+// the user.
+func (p *innerProblem) setNumOption(param string, value float64) bool {
+	cparam := C.CString(param)
+	ok := C.ipopt_problem_add_num_option(p.problem, cparam, C.double(value))
+	C.free(unsafe.Pointer(cparam))
+	return bool(ok)
+}
+
+// setScaling sets user scaling factors and switches nlp_scaling_method to
+// user-scaling; nil slices leave the variables or constraints unscaled.
+func (p *innerProblem) setScaling(objScale float64, xScale []float64, gScale []float64) error {
+	if xScale != nil && len(xScale) != p.n {
+		return fmt.Errorf("x scaling has %d entries, want %d", len(xScale), p.n)
+	}
+	if gScale != nil && len(gScale) != p.m {
+		return fmt.Errorf("g scaling has %d entries, want %d", len(gScale), p.m)
+	}
+
+	cxScale := toCFloatArray(xScale)
+	cgScale := toCFloatArray(gScale)
+	C.ipopt_problem_set_problem_scaling(p.problem, C.double(objScale),
+		toCFloatPtr(cxScale), toCFloatPtr(cgScale))
+	return p.addStrOption("nlp_scaling_method", "user-scaling")
 }
 
 func (p *innerProblem) free() {
+	if p.problem == nil {
+		return
+	}
 	C.ipopt_problem_free(p.problem)
 	p.problem = nil
+	p.handle.Delete()
+}
+
+func (p *innerProblem) closed() bool {
+	return p.problem == nil
 }
 
 func toCFloatArray(x []float64) []C.double {
@@ -298,6 +844,21 @@ func toCopyFloatArray(srv []C.double, x []float64) []float64 {
 	return x
 }
 
+func toCFloatPtr(x []C.double) *C.double {
+	if len(x) == 0 {
+		return nil
+	}
+	return &x[0]
+}
+
+func toGoFloat64Array(x []C.double) []float64 {
+	v := make([]float64, len(x))
+	for i := 0; i < len(x); i++ {
+		v[i] = float64(x[i])
+	}
+	return v
+}
+
 func toGoFloatArray(x []C.float) []float32 {
 	v := make([]float32, len(x))
 	for i := 0; i < len(x); i++ {
@@ -2,6 +2,7 @@
 #define GO_IPOPT_H_
 
 #include <stdbool.h>
+#include <stdint.h>
 
 #if defined(WIN32) || defined(WINDOWS) || defined(_WIN32) || defined(_WINDOWS)
 #define IPOPTCAPICALL __declspec(dllexport)
@@ -9,6 +10,10 @@
 #define IPOPTCAPICALL
 #endif
 
+#ifdef __cplusplus
+extern "C" {
+#endif
+
 enum ipopt_return_status {
   solve_succeeded = 0,
   solved_to_acceptable_level = 1,
@@ -49,6 +54,11 @@ typedef bool (*eval_jac_g_cb)(int n, double *x, bool new_x, int m, int nele_jac,
 typedef bool (*eval_h_cb)(int n, double *x, bool new_x, double obj_factor, int m,
                           double *lambda, bool new_lambda, int nele_hess,
                           int *iRow, int *jCol, double *values, void *user_data);
+typedef bool (*intermediate_cb)(int alg_mod, int iter_count, double obj_value,
+                                double inf_pr, double inf_du, double mu,
+                                double d_norm, double regularization_size,
+                                double alpha_du, double alpha_pr, int ls_trials,
+                                void *user_data);
 
 typedef struct _ipopt_problem_t ipopt_problem_t;
 
@@ -57,21 +67,99 @@ ipopt_problem_create(int n, double *xL, double *xU, int m, double *gl, double *g
                      int nnzj, int nnzh, eval_f_cb eval_f,
                      eval_grad_f_cb eval_grad_f, eval_g_cb eval_g,
                      eval_jac_g_cb eval_jac_g, eval_h_cb eval_h);
-IPOPTCAPICALL void ipopt_problem_add_str_option(ipopt_problem_t *p,
+IPOPTCAPICALL bool ipopt_problem_add_str_option(ipopt_problem_t *p,
                                                 const char *param,
                                                 const char *value);
-IPOPTCAPICALL void ipopt_problem_add_int_option(ipopt_problem_t *p,
+IPOPTCAPICALL bool ipopt_problem_add_int_option(ipopt_problem_t *p,
                                                 const char *param, int value);
-IPOPTCAPICALL void ipopt_problem_add_num_option(ipopt_problem_t *p,
-                                                const char *param, float value);
+IPOPTCAPICALL bool ipopt_problem_add_num_option(ipopt_problem_t *p,
+                                                const char *param, double value);
 IPOPTCAPICALL void ipopt_problem_set_problem_scaling(ipopt_problem_t *p,
-                                                     float obj_scaling,
-                                                     float *x_scaling,
-                                                     float *g_scaling);
+                                                     double obj_scaling,
+                                                     double *x_scaling,
+                                                     double *g_scaling);
+IPOPTCAPICALL bool ipopt_problem_open_output_file(ipopt_problem_t *p,
+                                                  const char *file_name,
+                                                  int print_level);
+IPOPTCAPICALL bool ipopt_problem_set_output(ipopt_problem_t *p,
+                                            uintptr_t user_data);
+IPOPTCAPICALL void
+ipopt_problem_set_intermediate_callback(ipopt_problem_t *p,
+                                        intermediate_cb intermediate);
+IPOPTCAPICALL bool ipopt_problem_get_current_iterate(
+    ipopt_problem_t *p, bool scaled, int n, double *x, double *z_L, double *z_U,
+    int m, double *g, double *lambda);
+IPOPTCAPICALL bool ipopt_problem_get_current_violations(
+    ipopt_problem_t *p, bool scaled, int n, double *x_L_violation,
+    double *x_U_violation, double *compl_x_L, double *compl_x_U,
+    double *grad_lag_x, int m, double *nlp_constraint_violation,
+    double *compl_g);
 IPOPTCAPICALL enum ipopt_return_status
 ipopt_problem_solve(ipopt_problem_t *p, double *x, double *g, double *obj_val,
                     double *mult_g, double *mult_x_L, double *mult_x_U,
-                    char *user_data);
+                    uintptr_t user_data);
 IPOPTCAPICALL void ipopt_problem_free(ipopt_problem_t *p);
 
+typedef struct ipopt_solve_statistics {
+  int iteration_count;
+  double total_cpu_time;
+  double total_sys_time;
+  double total_wallclock_time;
+  int num_obj_evals;
+  int num_constr_evals;
+  int num_obj_grad_evals;
+  int num_constr_jac_evals;
+  int num_hess_evals;
+  double dual_inf;
+  double constr_viol;
+  double varbounds_viol;
+  double complementarity;
+  double kkt_error;
+  double scaled_dual_inf;
+  double scaled_constr_viol;
+  double scaled_varbounds_viol;
+  double scaled_complementarity;
+  double scaled_kkt_error;
+  double final_objective;
+  double final_scaled_objective;
+} ipopt_solve_statistics;
+
+IPOPTCAPICALL bool ipopt_problem_get_statistics(ipopt_problem_t *p,
+                                                ipopt_solve_statistics *stats);
+
+enum ipopt_option_type {
+  ipopt_option_unknown = -1,
+  ipopt_option_number = 0,
+  ipopt_option_integer = 1,
+  ipopt_option_string = 2
+};
+
+typedef struct ipopt_option_info {
+  const char *name;
+  int type;
+  const char *category;
+  const char *short_description;
+  const char *long_description;
+  bool advanced;
+  double default_number;
+  int default_integer;
+  const char *default_string;
+  bool has_lower;
+  bool lower_strict;
+  double lower;
+  bool has_upper;
+  bool upper_strict;
+  double upper;
+  int num_values;
+} ipopt_option_info;
+
+IPOPTCAPICALL int ipopt_option_count(void);
+IPOPTCAPICALL bool ipopt_option_get(int i, ipopt_option_info *info);
+IPOPTCAPICALL bool ipopt_option_get_value(int i, int j, const char **value,
+                                          const char **description);
+
+#ifdef __cplusplus
+}
+#endif
+
 #endif
\ No newline at end of file
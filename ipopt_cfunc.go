@@ -1,15 +1,54 @@
 package ipopt
 
 // #include <stdbool.h>
+// #include <stdint.h>
 import "C"
 import (
+	"errors"
 	"math"
+	"runtime/cgo"
+	"runtime/debug"
 	"unsafe"
 )
 
+// recoverPanic must be deferred by every exported callback. It turns a
+// panic into a PanicError that makes the remaining callbacks fail, so that
+// Ipopt stops at the next intermediate callback.
+func (p *problemCallback) recoverPanic(callback string, ret *C.bool) {
+	if r := recover(); r != nil {
+		p.err = &PanicError{Callback: callback, Value: r, Stack: debug.Stack()}
+		*ret = false
+	}
+}
+
+// result maps the error of a callback to the value returned to Ipopt.
+// ErrDomain makes Ipopt shorten the step; any other error is recorded as a
+// CallbackError and stops the solve like a panic does.
+func (p *problemCallback) result(callback string, err error) C.bool {
+	if err == nil {
+		return true
+	}
+	if !errors.Is(err, ErrDomain) {
+		p.err = &CallbackError{Callback: callback, Err: err}
+	}
+	return false
+}
+
+// callbackOf resolves the user_data handed to Ipopt back to the callbacks
+// of the problem being solved.
+func callbackOf(userData C.uintptr_t) *problemCallback {
+	return cgo.Handle(userData).Value().(*problemCallback)
+}
+
 //export evalFunc
-func evalFunc(n C.int, x *C.double, newX C.bool, objValue *C.double, userData unsafe.Pointer) C.bool {
-	p := (*problemCallback)(userData)
+func evalFunc(n C.int, x *C.double, newX C.bool, objValue *C.double, userData C.uintptr_t) (ret C.bool) {
+	p := callbackOf(userData)
+	if p.err != nil {
+		return false
+	}
+	defer p.recoverPanic("Eval", &ret)
+	defer p.stats.Eval.track()()
+
 	if p.eval != nil {
 		var goX []float64
 		if x == nil {
@@ -21,14 +60,20 @@ func evalFunc(n C.int, x *C.double, newX C.bool, objValue *C.double, userData un
 		// objValue — это одно число, а не массив
 		goObjValue := (*float64)(unsafe.Pointer(objValue))
 
-		return (C.bool)(p.eval(goX, bool(newX), goObjValue))
+		return p.result("Eval", p.eval(goX, bool(newX), goObjValue))
 	}
 	return false
 }
 
 //export evalGradFunc
-func evalGradFunc(n C.int, x *C.double, newX C.bool, grad *C.double, userData unsafe.Pointer) C.bool {
-	p := (*problemCallback)(userData)
+func evalGradFunc(n C.int, x *C.double, newX C.bool, grad *C.double, userData C.uintptr_t) (ret C.bool) {
+	p := callbackOf(userData)
+	if p.err != nil {
+		return false
+	}
+	defer p.recoverPanic("EvalGrad", &ret)
+	defer p.stats.EvalGrad.track()()
+
 	if p.evalGrad != nil {
 		var goX []float64
 		if x == nil {
@@ -44,14 +89,20 @@ func evalGradFunc(n C.int, x *C.double, newX C.bool, grad *C.double, userData un
 			goGrad = (*[(math.MaxInt32 - 1) / unsafe.Sizeof(*grad)]float64)(unsafe.Pointer(grad))[:n:n]
 		}
 
-		return (C.bool)(p.evalGrad(goX, bool(newX), goGrad))
+		return p.result("EvalGrad", p.evalGrad(goX, bool(newX), goGrad))
 	}
 	return false
 }
 
 //export evalGFunc
-func evalGFunc(n C.int, x *C.double, newX C.bool, m C.int, g *C.double, userData unsafe.Pointer) C.bool {
-	p := (*problemCallback)(userData)
+func evalGFunc(n C.int, x *C.double, newX C.bool, m C.int, g *C.double, userData C.uintptr_t) (ret C.bool) {
+	p := callbackOf(userData)
+	if p.err != nil {
+		return false
+	}
+	defer p.recoverPanic("EvalG", &ret)
+	defer p.stats.EvalG.track()()
+
 	if p.evalG != nil {
 		var goX []float64
 		if x == nil {
@@ -67,14 +118,21 @@ func evalGFunc(n C.int, x *C.double, newX C.bool, m C.int, g *C.double, userData
 			gog = (*[(math.MaxInt32 - 1) / unsafe.Sizeof(*g)]float64)(unsafe.Pointer(g))[:m:m]
 		}
 
-		return (C.bool)(p.evalG(goX, bool(newX), int(m), gog))
+		return p.result("EvalG", p.evalG(goX, bool(newX), int(m), gog))
 	}
-	return false
+	// Problems without constraints need no constraint callbacks.
+	return m == 0
 }
 
 //export evalJacGFunc
-func evalJacGFunc(n C.int, x *C.double, newX C.bool, m C.int, nele_jac C.int, iRow *C.int, jCol *C.int, values *C.double, userData unsafe.Pointer) C.bool {
-	p := (*problemCallback)(userData)
+func evalJacGFunc(n C.int, x *C.double, newX C.bool, m C.int, nele_jac C.int, iRow *C.int, jCol *C.int, values *C.double, userData C.uintptr_t) (ret C.bool) {
+	p := callbackOf(userData)
+	if p.err != nil {
+		return false
+	}
+	defer p.recoverPanic("EvalJacG", &ret)
+	defer p.stats.EvalJacG.track()()
+
 	if p.evalJacG != nil {
 		var goX []float64
 		if x == nil {
@@ -104,15 +162,27 @@ func evalJacGFunc(n C.int, x *C.double, newX C.bool, m C.int, nele_jac C.int, iR
 			gojCol = nil
 		}
 
+		if values == nil && p.jacStructure[0] != nil {
+			copy(goiRow, p.jacStructure[0])
+			copy(gojCol, p.jacStructure[1])
+			return true
+		}
+
 		jac := [2][]int32{goiRow, gojCol}
-		return (C.bool)(p.evalJacG(goX, bool(newX), int(m), jac, govalues))
+		return p.result("EvalJacG", p.evalJacG(goX, bool(newX), int(m), jac, govalues))
 	}
-	return false
+	return m == 0
 }
 
 //export evalHFunc
-func evalHFunc(n C.int, x *C.double, newX C.bool, objFactor C.double, m C.int, lambda *C.double, newLambda C.bool, nele_hess C.int, iRow *C.int, jCol *C.int, values *C.double, userData unsafe.Pointer) C.bool {
-	p := (*problemCallback)(userData)
+func evalHFunc(n C.int, x *C.double, newX C.bool, objFactor C.double, m C.int, lambda *C.double, newLambda C.bool, nele_hess C.int, iRow *C.int, jCol *C.int, values *C.double, userData C.uintptr_t) (ret C.bool) {
+	p := callbackOf(userData)
+	if p.err != nil {
+		return false
+	}
+	defer p.recoverPanic("EvalH", &ret)
+	defer p.stats.EvalH.track()()
+
 	if p.evalH != nil {
 		var goX []float64
 		if x == nil {
@@ -149,8 +219,73 @@ func evalHFunc(n C.int, x *C.double, newX C.bool, objFactor C.double, m C.int, l
 			gojCol = nil
 		}
 
+		if values == nil && p.hessStructure[0] != nil {
+			copy(goiRow, p.hessStructure[0])
+			copy(gojCol, p.hessStructure[1])
+			return true
+		}
+
 		hess := [2][]int32{goiRow, gojCol}
-		return (C.bool)(p.evalH(goX, bool(newX), float64(objFactor), int(m), golambda, bool(newLambda), hess, govalues))
+		return p.result("EvalH", p.evalH(goX, bool(newX), float64(objFactor), int(m), golambda, bool(newLambda), hess, govalues))
 	}
 	return false
 }
+
+//export intermediateFunc
+func intermediateFunc(algMod C.int, iterCount C.int, objValue C.double, infPr C.double, infDu C.double, mu C.double, dNorm C.double, regularizationSize C.double, alphaDu C.double, alphaPr C.double, lsTrials C.int, userData C.uintptr_t) (ret C.bool) {
+	p := callbackOf(userData)
+	if p.err != nil {
+		return false
+	}
+	defer p.recoverPanic("Intermediate", &ret)
+	defer p.stats.Intermediate.track()()
+
+	p.iter = int(iterCount)
+	state := &iterationState{p: p.inner}
+	defer func() { state.p = nil }()
+
+	it := Iteration{
+		Mode:               AlgorithmMode(algMod),
+		Iter:               int(iterCount),
+		ObjValue:           float64(objValue),
+		InfPr:              float64(infPr),
+		InfDu:              float64(infDu),
+		Mu:                 float64(mu),
+		DNorm:              float64(dNorm),
+		RegularizationSize: float64(regularizationSize),
+		AlphaDu:            float64(alphaDu),
+		AlphaPr:            float64(alphaPr),
+		LsTrials:           int(lsTrials),
+		state:              state,
+	}
+	if p.logger != nil {
+		p.logIteration(it)
+	}
+	for _, o := range p.observers {
+		o.observeIteration(it)
+	}
+
+	if p.ctx != nil && p.ctx.Err() != nil {
+		return false
+	}
+	if p.intermediate != nil && !p.intermediate(it) {
+		return false
+	}
+	// A cancel from inside the callback stops at this iterate, not the next.
+	return p.ctx == nil || p.ctx.Err() == nil
+}
+
+//export outputFunc
+func outputFunc(str *C.char, n C.int, userData C.uintptr_t) {
+	p := callbackOf(userData)
+	if p.output == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			p.err = &PanicError{Callback: "Output", Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	p.output.Write(C.GoBytes(unsafe.Pointer(str), n))
+}
@@ -1,7 +1,20 @@
 package ipopt
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"gonum.org/v1/gonum/diff/fd"
 	"gonum.org/v1/gonum/mat"
@@ -265,8 +278,9 @@ func TestVersion(t *testing.T) {
 
 	problem, err := NewProblem(opt)
 	if err != nil {
-		t.Fail()
+		t.Fatal(err)
 	}
+	defer problem.Close()
 
 	problem.AddNumOption("tol", 3.82e-6)
 	problem.AddStrOption("mu_strategy", "adaptive")
@@ -285,7 +299,7 @@ func TestVersion(t *testing.T) {
 
 	objVal := []float64{0}
 
-	objVal, status := p.problem.Solve(x, nil, objVal, mult_g, mult_x_L, mult_x_U, true)
+	objVal, status := p.problem.Solve(x, nil, objVal, mult_g, mult_x_L, mult_x_U, false)
 	if status == nil {
 
 	}
@@ -302,4 +316,828 @@ func TestVersion(t *testing.T) {
 	}
 
 	fmt.Println(x)
+
+	if err := problem.AddStrOption("mu_strategy", "monotone"); !errors.Is(err, ErrProblemClosed) {
+		t.Fatalf("AddStrOption after free: got %v, want ErrProblemClosed", err)
+	}
+}
+
+// hs071Options собирает ProblemOptions для задачи HS071 на основе MyProblem.
+func hs071Options(p *MyProblem) ProblemOptions {
+	return ProblemOptions{
+		Variables:              [2][]float64{{1, 1, 1, 1}, {5, 5, 5, 5}},
+		Constraints:            [2][]float64{{25, 40}, {2e19, 40}},
+		NumConstraintJacobian:  8,
+		NumHessianOfLagrangian: 10,
+		Eval:                   p.evalF,
+		EvalGrad:               p.evalGradF,
+		EvalG:                  p.evalG,
+		EvalJacG:               p.evalJacG,
+		EvalH:                  p.evalH,
+	}
+}
+
+func TestConcurrentSolve(t *testing.T) {
+	const workers = 8
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			p := &MyProblem{}
+			opt := hs071Options(p)
+
+			// Каждая задача считает только свои вызовы целевой функции.
+			evals := 0
+			opt.Eval = func(x []float64, newX bool, objValue *float64) bool {
+				evals++
+				return p.evalF(x, newX, objValue)
+			}
+
+			problem, err := NewProblem(opt)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer problem.Close()
+
+			problem.AddIntOption("print_level", 0)
+			problem.AddStrOption("sb", "yes")
+
+			res, err := problem.Optimize([]float64{1, 5, 5, 1})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			if math.Abs(res.Objective-17.014017) > 1e-4 {
+				t.Errorf("objective = %v, want 17.014017", res.Objective)
+			}
+			if evals == 0 {
+				t.Error("objective callback was never called")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestIntermediateStop(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	calls := 0
+	opt.Intermediate = func(it Iteration) bool {
+		calls++
+		// Останавливаем решение на второй итерации
+		return it.Iter < 2
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+
+	res, err := problem.Optimize([]float64{1, 5, 5, 1})
+	if !errors.Is(err, ErrUserRequestedStop) {
+		t.Fatalf("Optimize error = %v, want ErrUserRequestedStop", err)
+	}
+	if res.Status != IPOPT_USER_REQUESTED_STOP {
+		t.Errorf("status = %v, want %v", res.Status, IPOPT_USER_REQUESTED_STOP)
+	}
+	if calls != 3 {
+		t.Errorf("Intermediate called %d times, want 3", calls)
+	}
+}
+
+func TestIterationIterate(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	var kept Iteration
+	opt.Intermediate = func(it Iteration) bool {
+		kept = it
+		for _, scaled := range []bool{false, true} {
+			cur, err := it.Iterate(scaled)
+			if err != nil {
+				t.Error(err)
+				return false
+			}
+			if len(cur.X) != 4 || len(cur.ZL) != 4 || len(cur.ZU) != 4 || len(cur.G) != 2 || len(cur.Lambda) != 2 {
+				t.Errorf("iterate lengths: %+v", cur)
+			}
+
+			viol, err := it.Violations(scaled)
+			if err != nil {
+				t.Error(err)
+				return false
+			}
+			if len(viol.XLViolation) != 4 || len(viol.XUViolation) != 4 || len(viol.ComplXL) != 4 ||
+				len(viol.ComplXU) != 4 || len(viol.GradLagX) != 4 ||
+				len(viol.ConstraintViolation) != 2 || len(viol.ComplG) != 2 {
+				t.Errorf("violation lengths: %+v", viol)
+			}
+		}
+		return true
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+
+	// Итерация, созданная не Ipopt, не даёт доступа к решателю
+	if _, err := (Iteration{}).Iterate(false); err == nil {
+		t.Error("Iterate of a zero Iteration: expected error")
+	}
+	if _, err := (Iteration{}).Violations(false); err == nil {
+		t.Error("Violations of a zero Iteration: expected error")
+	}
+
+	if _, err := problem.Optimize([]float64{1, 5, 5, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Сохранённая итерация после возврата из callback недействительна
+	if _, err := kept.Iterate(false); err == nil {
+		t.Error("Iterate after the callback returned: expected error")
+	}
+	if _, err := kept.Violations(false); err == nil {
+		t.Error("Violations after the callback returned: expected error")
+	}
+}
+
+// TestIterateDuringClose проверяется с -race: Close из другой горутины
+// ждёт конца решения и не освобождает задачу под работающим callback.
+func TestIterateDuringClose(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	var problem *Problem
+	closed := make(chan struct{})
+	var once sync.Once
+	opt.Intermediate = func(it Iteration) bool {
+		once.Do(func() {
+			go func() {
+				problem.Close()
+				close(closed)
+			}()
+		})
+		if _, err := it.Iterate(false); err != nil {
+			t.Error(err)
+			return false
+		}
+		return true
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+	if _, err := problem.Optimize([]float64{1, 5, 5, 1}); err != nil {
+		t.Fatal(err)
+	}
+	<-closed
+
+	if _, err := problem.Optimize([]float64{1, 5, 5, 1}); !errors.Is(err, ErrProblemClosed) {
+		t.Errorf("Optimize after Close: got %v, want ErrProblemClosed", err)
+	}
+}
+
+func TestCallbackPanic(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	calls := 0
+	opt.EvalGrad = func(x []float64, newX bool, grad []float64) bool {
+		calls++
+		if calls == 3 {
+			panic("boom")
+		}
+		return p.evalGradF(x, newX, grad)
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+
+	_, err = problem.Optimize([]float64{1, 5, 5, 1})
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Optimize error = %v, want *PanicError", err)
+	}
+	if pe.Callback != "EvalGrad" || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Fatalf("unexpected panic error: %+v", pe)
+	}
+	if msg := err.Error(); msg != "panic in EvalGrad callback: boom" {
+		t.Errorf("Error() = %q", msg)
+	}
+}
+
+func TestCallbackError(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	errStore := errors.New("store unavailable")
+	opt.Eval = nil
+	opt.EvalErr = func(x []float64, _ bool, objValue *float64) error {
+		if x[0] > 1.5 {
+			return errStore
+		}
+		*objValue = p.targetFunc(x)
+		return nil
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+
+	_, err = problem.Optimize([]float64{2, 5, 5, 1})
+	if !errors.Is(err, errStore) {
+		t.Fatalf("Optimize error = %v, want %v", err, errStore)
+	}
+
+	var ce *CallbackError
+	if !errors.As(err, &ce) || ce.Callback != "Eval" {
+		t.Fatalf("Optimize error = %v, want *CallbackError from Eval", err)
+	}
+}
+
+func TestDeclaredStructure(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	opt.NumConstraintJacobian = 0
+	opt.JacobianStructure = [2][]int32{
+		{0, 0, 0, 0, 1, 1, 1, 1},
+		{0, 1, 2, 3, 0, 1, 2, 3},
+	}
+	opt.EvalJacG = func(x []float64, newX bool, m int, jac [2][]int32, values []float64) bool {
+		if values == nil {
+			t.Error("structure query reached EvalJacG")
+		}
+		return p.evalJacG(x, newX, m, jac, values)
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+
+	res, err := problem.Optimize([]float64{1, 5, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Objective-17.014017) > 1e-4 {
+		t.Errorf("objective = %v, want 17.014017", res.Objective)
+	}
+
+	opt.NumConstraintJacobian = 7
+	if _, err := NewProblem(opt); err == nil {
+		t.Error("NewProblem accepted a structure that disagrees with NumConstraintJacobian")
+	}
+}
+
+func TestBoundConstrained(t *testing.T) {
+	// Задача только с ограничениями на переменные: без EvalG и EvalJacG
+	problem, err := NewProblem(ProblemOptions{
+		Variables: [2][]float64{{0, 0}, {1, 1}},
+		Eval: func(x []float64, newX bool, objValue *float64) bool {
+			*objValue = (x[0]-2)*(x[0]-2) + (x[1]+1)*(x[1]+1)
+			return true
+		},
+		EvalGrad: func(x []float64, newX bool, grad []float64) bool {
+			grad[0] = 2 * (x[0] - 2)
+			grad[1] = 2 * (x[1] + 1)
+			return true
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+
+	res, err := problem.Optimize([]float64{0.5, 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.X[0]-1) > 1e-6 || math.Abs(res.X[1]) > 1e-6 {
+		t.Errorf("x = %v, want [1 0]", res.X)
+	}
+	if len(res.G) != 0 || len(res.MultG) != 0 {
+		t.Errorf("G = %v, MultG = %v, want empty", res.G, res.MultG)
+	}
+
+	// Solve без g и multG
+	x := []float64{0.5, 0.5}
+	objVal := []float64{0}
+	if _, err := problem.Solve(x, nil, objVal, nil, make([]float64, 2), make([]float64, 2), false); err != nil {
+		t.Fatal(err)
+	}
+
+	// Неверная длина начальной точки
+	if _, err := problem.Solve([]float64{0.5}, nil, objVal, nil, nil, nil, false); err == nil {
+		t.Error("expected error for short x")
+	}
+	if _, err := problem.Optimize([]float64{0.5, 0.5, 0.5}); err == nil {
+		t.Error("expected error for long x0")
+	}
+
+	// Warm start без ограничений: multG пуст
+	warm, err := problem.WarmStart(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(warm.X[0]-1) > 1e-6 || math.Abs(warm.X[1]) > 1e-6 {
+		t.Errorf("warm start x = %v, want [1 0]", warm.X)
+	}
+}
+
+func TestInvalidProblem(t *testing.T) {
+	eval := func(x []float64, newX bool, objValue *float64) bool { return true }
+	grad := func(x []float64, newX bool, grad []float64) bool { return true }
+
+	cases := map[string]ProblemOptions{
+		"no variables": {Eval: eval, EvalGrad: grad},
+		"no objective": {
+			Variables: [2][]float64{{0}, {1}},
+			EvalGrad:  grad,
+		},
+		"no gradient": {
+			Variables: [2][]float64{{0}, {1}},
+			Eval:      eval,
+		},
+		"jacobian without constraints": {
+			Variables:             [2][]float64{{0}, {1}},
+			NumConstraintJacobian: 1,
+			Eval:                  eval,
+			EvalGrad:              grad,
+		},
+		"constraints without jacobian": {
+			Variables:   [2][]float64{{0}, {1}},
+			Constraints: [2][]float64{{0}, {1}},
+			Eval:        eval,
+			EvalGrad:    grad,
+		},
+		"constraints without EvalG": {
+			Variables:             [2][]float64{{0}, {1}},
+			Constraints:           [2][]float64{{0}, {1}},
+			NumConstraintJacobian: 1,
+			Eval:                  eval,
+			EvalGrad:              grad,
+		},
+	}
+	for name, opt := range cases {
+		if p, err := NewProblem(opt); err == nil {
+			p.Close()
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestOptionError(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	if err := problem.AddStrOption("mu_strategy", "adaptive"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"unknown", problem.AddStrOption("mu_stratgy", "adaptive"), ErrUnknownOption},
+		{"type", problem.AddIntOption("tol", 1), ErrOptionType},
+		{"range", problem.AddNumOption("tol", -1), ErrOptionValue},
+		{"value", problem.AddStrOption("mu_strategy", "fast"), ErrOptionValue},
+	}
+	for _, tt := range tests {
+		var oe *OptionError
+		if !errors.As(tt.err, &oe) {
+			t.Errorf("%s: got %v, want *OptionError", tt.name, tt.err)
+			continue
+		}
+		if !errors.Is(tt.err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.err, tt.want)
+		}
+	}
+}
+
+func TestSetScaling(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	// Длины должны совпадать с числом переменных и ограничений
+	if err := problem.SetScaling(1, []float64{1, 1}, nil); err == nil {
+		t.Error("expected error for short xScale")
+	}
+	if err := problem.SetScaling(1, nil, []float64{1, 1, 1}); err == nil {
+		t.Error("expected error for long gScale")
+	}
+
+	if err := problem.SetScaling(2, []float64{1, 1, 1, 1}, []float64{0.5, 0.5}); err != nil {
+		t.Fatal(err)
+	}
+	problem.AddIntOption("print_level", 0)
+	problem.AddNumOption("tol", 1e-12)
+
+	res, err := problem.Optimize([]float64{1, 5, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(res.Objective-17.014017) > 1e-4 {
+		t.Errorf("objective = %v, want 17.014017", res.Objective)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	var wg sync.WaitGroup
+	bufs := make([]bytes.Buffer, 4)
+
+	// Каждая задача пишет в свой буфер
+	for i := range bufs {
+		wg.Add(1)
+		go func(buf *bytes.Buffer) {
+			defer wg.Done()
+
+			p := &MyProblem{}
+			problem, err := NewProblem(hs071Options(p))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer problem.Close()
+
+			if err := problem.SetOutput(buf, 5); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err := problem.Optimize([]float64{1, 5, 5, 1}); err != nil {
+				t.Error(err)
+			}
+		}(&bufs[i])
+	}
+	wg.Wait()
+
+	for i := range bufs {
+		out := bufs[i].String()
+		if !strings.Contains(out, "iter") || !strings.Contains(out, "EXIT: Optimal Solution Found.") {
+			t.Errorf("output %d:\n%s", i, out)
+		}
+	}
+
+	if err := (&Problem{inner: &innerProblem{}}).SetOutput(io.Discard, 5); err != ErrProblemClosed {
+		t.Errorf("got %v, want ErrProblemClosed", err)
+	}
+}
+
+func TestOpenOutputFile(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.SetOutput(io.Discard, 0)
+	path := filepath.Join(t.TempDir(), "ipopt.out")
+	if err := problem.OpenOutputFile(path, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := problem.Optimize([]float64{1, 5, 5, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("EXIT: Optimal Solution Found.")) {
+		t.Errorf("output file:\n%s", out)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p := &MyProblem{}
+	opt := hs071Options(p)
+	opt.Logger = logger
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+	res, err := problem.Optimize([]float64{1, 5, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Считаем записи по типу сообщения
+	counts := map[string]int{}
+	var last map[string]any
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var rec map[string]any
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		counts[rec["msg"].(string)]++
+		last = rec
+	}
+
+	if counts["ipopt solve started"] != 1 || counts["ipopt solve finished"] != 1 {
+		t.Errorf("records = %v", counts)
+	}
+	// Итерация 0 тоже передаётся в intermediate callback
+	if counts["ipopt iteration"] != res.Iterations+1 {
+		t.Errorf("%d iteration records, want %d", counts["ipopt iteration"], res.Iterations+1)
+	}
+	if last["status"] != IPOPT_SOLVE_SUCCEEDED.String() {
+		t.Errorf("last record = %v", last)
+	}
+}
+
+func TestClosedProblemSetters(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := problem.SetLogger(slog.Default()); err != nil {
+		t.Fatal(err)
+	}
+	if err := problem.SetIntermediateCallback(func(Iteration) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+
+	problem.Close()
+	if err := problem.SetLogger(nil); !errors.Is(err, ErrProblemClosed) {
+		t.Errorf("SetLogger after Close: got %v, want ErrProblemClosed", err)
+	}
+	if err := problem.SetIntermediateCallback(nil); !errors.Is(err, ErrProblemClosed) {
+		t.Errorf("SetIntermediateCallback after Close: got %v, want ErrProblemClosed", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+	res, err := problem.Optimize([]float64{1, 5, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := res.Stats
+	if s.Iterations != res.Iterations {
+		t.Errorf("Stats.Iterations = %d, want %d", s.Iterations, res.Iterations)
+	}
+	if math.Abs(s.Objective-res.Objective) > 1e-12 {
+		t.Errorf("Stats.Objective = %v, want %v", s.Objective, res.Objective)
+	}
+	if s.NLPError > 1e-8 || s.ConstraintViolation > 1e-8 {
+		t.Errorf("NLPError = %v, ConstraintViolation = %v", s.NLPError, s.ConstraintViolation)
+	}
+	if s.WallTime <= 0 || s.CallbackTime > s.WallTime {
+		t.Errorf("WallTime = %v, CallbackTime = %v", s.WallTime, s.CallbackTime)
+	}
+
+	// Каждый callback вызывался хотя бы раз
+	for name, c := range map[string]CallbackStats{
+		"Eval": s.Eval, "EvalGrad": s.EvalGrad, "EvalG": s.EvalG,
+		"EvalJacG": s.EvalJacG, "EvalH": s.EvalH, "Intermediate": s.Intermediate,
+	} {
+		if c.Calls == 0 {
+			t.Errorf("%s was not counted", name)
+		}
+	}
+	if s.Intermediate.Calls != res.Iterations+1 {
+		t.Errorf("Intermediate.Calls = %d, want %d", s.Intermediate.Calls, res.Iterations+1)
+	}
+
+	// Ipopt считает только вычисления значений, не больше вызовов callback
+	e := s.Evaluations
+	if e.Objective == 0 || e.Objective > s.Eval.Calls || e.Gradient > s.EvalGrad.Calls ||
+		e.Constraints > s.EvalG.Calls || e.Jacobian > s.EvalJacG.Calls || e.Hessian > s.EvalH.Calls {
+		t.Errorf("Evaluations = %+v, callbacks = %+v", e, s)
+	}
+}
+
+func TestWarmStart(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+	problem.AddNumOption("warm_start_mult_bound_push", 1e-6)
+	var before bytes.Buffer
+	problem.WriteOptions(&before)
+
+	cold, err := problem.Optimize([]float64{1, 5, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	problem.AddNumOption("mu_init", 1e-6)
+	warm, err := problem.WarmStart(cold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(warm.Objective-cold.Objective) > 1e-6 {
+		t.Errorf("objective = %v, want %v", warm.Objective, cold.Objective)
+	}
+	if warm.Iterations >= cold.Iterations {
+		t.Errorf("warm start took %d iterations, cold start %d", warm.Iterations, cold.Iterations)
+	}
+
+	// Опции warm start восстановлены, заданные пользователем не тронуты
+	problem.AddNumOption("mu_init", 0.1)
+	var after bytes.Buffer
+	problem.WriteOptions(&after)
+	if want := "mu_init 0.1\n" + before.String(); after.String() != want {
+		t.Errorf("options after warm start:\n%s\nwant:\n%s", after.String(), want)
+	}
+
+	if _, err := problem.WarmStart(&Result{X: []float64{1}}); err == nil {
+		t.Error("expected error for mismatched previous result")
+	}
+}
+
+func TestSolveContextCancel(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var last []float64
+	opt.Intermediate = func(it Iteration) bool {
+		cur, err := it.Iterate(false)
+		if err != nil {
+			t.Error(err)
+			return false
+		}
+		last = cur.X
+		// Отмена изнутри callback: решение должно остановиться на этой итерации
+		if it.Iter == 3 {
+			cancel()
+		}
+		return true
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+
+	x := []float64{1, 5, 5, 1}
+	_, err = problem.SolveContext(ctx, x, nil, []float64{0}, nil, nil, nil, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SolveContext error = %v, want context.Canceled", err)
+	}
+	for i := range x {
+		if x[i] != last[i] {
+			t.Fatalf("x = %v, want last iterate %v", x, last)
+		}
+	}
+}
+
+// lateCancelContext отменяется, как только Ipopt печатает строку EXIT,
+// то есть после последней итерации.
+type lateCancelContext struct {
+	context.Context
+	done     chan struct{}
+	canceled atomic.Bool
+}
+
+func (c *lateCancelContext) Done() <-chan struct{} { return c.done }
+
+func (c *lateCancelContext) Err() error {
+	if c.canceled.Load() {
+		return context.Canceled
+	}
+	return nil
+}
+
+func (c *lateCancelContext) Write(b []byte) (int, error) {
+	if bytes.Contains(b, []byte("EXIT:")) {
+		c.canceled.Store(true)
+	}
+	return len(b), nil
+}
+
+func TestSolveContextLateCancel(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	ctx := &lateCancelContext{Context: context.Background(), done: make(chan struct{})}
+	if err := problem.SetOutput(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+
+	// Отмена после сходимости не превращает успешное решение в ошибку
+	x := []float64{1, 5, 5, 1}
+	if _, err := problem.SolveContext(ctx, x, nil, []float64{0}, nil, nil, nil, false); err != nil {
+		t.Fatalf("SolveContext error = %v, want nil", err)
+	}
+	if ctx.Err() == nil {
+		t.Fatal("context was not cancelled by the output")
+	}
+}
+
+func TestSolveContextDeadline(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+	opt.Eval = func(x []float64, newX bool, objValue *float64) bool {
+		time.Sleep(time.Millisecond)
+		return p.evalF(x, newX, objValue)
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	problem.AddIntOption("print_level", 0)
+	var before bytes.Buffer
+	problem.WriteOptions(&before)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	x := []float64{1, 5, 5, 1}
+	_, err = problem.SolveContext(ctx, x, nil, []float64{0}, nil, nil, nil, false)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SolveContext error = %v, want context.DeadlineExceeded", err)
+	}
+	if errors.Is(err, ErrMaximumWallTimeExceeded) {
+		t.Fatalf("SolveContext error = %v, must not be ErrMaximumWallTimeExceeded", err)
+	}
+
+	// max_wall_time от дедлайна не остаётся в опциях
+	var after bytes.Buffer
+	problem.WriteOptions(&after)
+	if after.String() != before.String() {
+		t.Errorf("options after deadline:\n%s\nwant:\n%s", after.String(), before.String())
+	}
+
+	// Следующее решение без дедлайна не ограничено по времени
+	x = []float64{1, 5, 5, 1}
+	if _, err := problem.Solve(x, nil, []float64{0}, nil, nil, nil, false); err != nil {
+		t.Fatalf("Solve after deadline: %v", err)
+	}
 }
@@ -0,0 +1,82 @@
+package ipopt
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// logStart records the problem dimensions and the options set on it.
+func (p *innerProblem) logStart(ctx context.Context) {
+	names := make([]string, 0, len(p.options))
+	for name := range p.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	opts := make([]any, 0, len(names))
+	for _, name := range names {
+		opts = append(opts, slog.Any(name, p.options[name]))
+	}
+
+	p.cb.logger.LogAttrs(ctx, slog.LevelInfo, "ipopt solve started",
+		slog.Int("variables", p.n),
+		slog.Int("constraints", p.m),
+		slog.Int("jacobian_nonzeros", p.nnzj),
+		slog.Int("hessian_nonzeros", p.nnzh),
+		slog.Group("options", opts...),
+	)
+}
+
+// logIteration records an iteration and any switch between the regular and
+// the restoration phase. It runs inside the intermediate callback.
+func (p *problemCallback) logIteration(it Iteration) {
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if it.Mode != p.mode {
+		msg := "ipopt restoration phase entered"
+		if it.Mode == RegularMode {
+			msg = "ipopt restoration phase left"
+		}
+		p.logger.LogAttrs(ctx, slog.LevelInfo, msg, slog.Int("iter", it.Iter))
+		p.mode = it.Mode
+	}
+
+	p.logger.LogAttrs(ctx, slog.LevelDebug, "ipopt iteration",
+		slog.Int("iter", it.Iter),
+		slog.String("mode", it.Mode.String()),
+		slog.Float64("objective", it.ObjValue),
+		slog.Float64("inf_pr", it.InfPr),
+		slog.Float64("inf_du", it.InfDu),
+		slog.Float64("mu", it.Mu),
+		slog.Float64("d_norm", it.DNorm),
+		slog.Float64("regularization", it.RegularizationSize),
+		slog.Float64("alpha_du", it.AlphaDu),
+		slog.Float64("alpha_pr", it.AlphaPr),
+		slog.Int("ls_trials", it.LsTrials),
+	)
+}
+
+// logFinish records the outcome of a solve.
+func (p *innerProblem) logFinish(ctx context.Context, ret Status, objective float64, d time.Duration, err error) {
+	level := slog.LevelInfo
+	if err != nil || !(ret.Succeeded() || ret.Acceptable()) {
+		level = slog.LevelWarn
+	}
+
+	attrs := []slog.Attr{
+		slog.String("status", ret.String()),
+		slog.Int("status_code", int(ret)),
+		slog.Int("iterations", p.cb.iter),
+		slog.Float64("objective", objective),
+		slog.Duration("duration", d),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+	}
+	p.cb.logger.LogAttrs(ctx, level, "ipopt solve finished", attrs...)
+}
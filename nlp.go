@@ -0,0 +1,88 @@
+package ipopt
+
+import "errors"
+
+// NLP is a nonlinear program given as a value instead of separate
+// callbacks in ProblemOptions. The Jacobian structure is queried once when
+// the problem is created and used like ProblemOptions.JacobianStructure;
+// Jacobian then only fills the values in the same order.
+type NLP interface {
+	Objective(x []float64, newX bool) (float64, error)
+	Gradient(x []float64, newX bool, grad []float64) error
+	Constraints(x []float64, newX bool, g []float64) error
+	JacobianStructure() (rows []int32, cols []int32)
+	Jacobian(x []float64, newX bool, values []float64) error
+}
+
+// HessianProvider is implemented by an NLP that evaluates the Hessian of
+// the Lagrangian. Without it Ipopt uses a limited-memory approximation, as
+// when ProblemOptions.EvalH is nil.
+type HessianProvider interface {
+	HessianStructure() (rows []int32, cols []int32)
+	Hessian(x []float64, newX bool, objFactor float64, lambda []float64, newLambda bool, values []float64) error
+}
+
+// ScalingProvider is implemented by an NLP that supplies its own scaling
+// factors. A nil xScale or gScale leaves the variables or constraints
+// unscaled.
+type ScalingProvider interface {
+	Scaling() (objScale float64, xScale []float64, gScale []float64)
+}
+
+// applyNLP fills the callback fields of opt from opt.NLP.
+func (opt *ProblemOptions) applyNLP() error {
+	if opt.Eval != nil || opt.EvalGrad != nil || opt.EvalG != nil || opt.EvalJacG != nil || opt.EvalH != nil ||
+		opt.EvalErr != nil || opt.EvalGradErr != nil || opt.EvalGErr != nil || opt.EvalJacGErr != nil || opt.EvalHErr != nil {
+		return errors.New("NLP cannot be combined with Eval callbacks")
+	}
+	if opt.JacobianStructure[0] != nil || opt.HessianStructure[0] != nil {
+		return errors.New("NLP cannot be combined with declared structures")
+	}
+
+	nlp := opt.NLP
+
+	rows, cols := nlp.JacobianStructure()
+	if len(rows) == 0 && len(opt.Constraints[0]) > 0 {
+		return errors.New("NLP has constraints but its Jacobian structure is empty")
+	}
+	opt.JacobianStructure = [2][]int32{rows, cols}
+
+	opt.EvalErr = func(x []float64, newX bool, objValue *float64) error {
+		v, err := nlp.Objective(x, newX)
+		if err != nil {
+			return err
+		}
+		*objValue = v
+		return nil
+	}
+	opt.EvalGradErr = nlp.Gradient
+	opt.EvalGErr = func(x []float64, newX bool, _ int, g []float64) error {
+		return nlp.Constraints(x, newX, g)
+	}
+	opt.EvalJacGErr = func(x []float64, newX bool, _ int, _ [2][]int32, values []float64) error {
+		return nlp.Jacobian(x, newX, values)
+	}
+
+	if hp, ok := nlp.(HessianProvider); ok {
+		rows, cols := hp.HessianStructure()
+		if len(rows) == 0 {
+			return errors.New("HessianProvider returned an empty Hessian structure")
+		}
+		opt.HessianStructure = [2][]int32{rows, cols}
+
+		opt.EvalHErr = func(x []float64, newX bool, objFactor float64, _ int, lambda []float64, newLambda bool, _ [2][]int32, values []float64) error {
+			return hp.Hessian(x, newX, objFactor, lambda, newLambda, values)
+		}
+	}
+	return nil
+}
+
+// configureNLP applies the optional capabilities of nlp to the created
+// problem.
+func (p *innerProblem) configureNLP(nlp NLP) error {
+	if sp, ok := nlp.(ScalingProvider); ok {
+		objScale, xScale, gScale := sp.Scaling()
+		return p.setScaling(objScale, xScale, gScale)
+	}
+	return nil
+}
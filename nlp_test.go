@@ -0,0 +1,152 @@
+package ipopt
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+// hs071NLP задаёт HS071 через интерфейс NLP с аналитическими производными.
+type hs071NLP struct{}
+
+func (hs071NLP) Objective(x []float64, _ bool) (float64, error) {
+	return x[0]*x[3]*(x[0]+x[1]+x[2]) + x[2], nil
+}
+
+func (hs071NLP) Gradient(x []float64, _ bool, grad []float64) error {
+	grad[0] = x[0]*x[3] + x[3]*(x[0]+x[1]+x[2])
+	grad[1] = x[0] * x[3]
+	grad[2] = x[0]*x[3] + 1
+	grad[3] = x[0] * (x[0] + x[1] + x[2])
+	return nil
+}
+
+func (hs071NLP) Constraints(x []float64, _ bool, g []float64) error {
+	g[0] = x[0] * x[1] * x[2] * x[3]
+	g[1] = x[0]*x[0] + x[1]*x[1] + x[2]*x[2] + x[3]*x[3]
+	return nil
+}
+
+func (hs071NLP) JacobianStructure() ([]int32, []int32) {
+	return []int32{0, 0, 0, 0, 1, 1, 1, 1}, []int32{0, 1, 2, 3, 0, 1, 2, 3}
+}
+
+func (hs071NLP) Jacobian(x []float64, _ bool, values []float64) error {
+	values[0] = x[1] * x[2] * x[3]
+	values[1] = x[0] * x[2] * x[3]
+	values[2] = x[0] * x[1] * x[3]
+	values[3] = x[0] * x[1] * x[2]
+	values[4] = 2 * x[0]
+	values[5] = 2 * x[1]
+	values[6] = 2 * x[2]
+	values[7] = 2 * x[3]
+	return nil
+}
+
+// hs071HessNLP дополнительно реализует HessianProvider.
+type hs071HessNLP struct{ hs071NLP }
+
+func (hs071HessNLP) HessianStructure() ([]int32, []int32) {
+	return []int32{0, 1, 1, 2, 2, 2, 3, 3, 3, 3}, []int32{0, 0, 1, 0, 1, 2, 0, 1, 2, 3}
+}
+
+func (hs071HessNLP) Hessian(x []float64, _ bool, objFactor float64, lambda []float64, _ bool, values []float64) error {
+	values[0] = objFactor*2*x[3] + 2*lambda[1]
+	values[1] = objFactor*x[3] + lambda[0]*x[2]*x[3]
+	values[2] = 2 * lambda[1]
+	values[3] = objFactor*x[3] + lambda[0]*x[1]*x[3]
+	values[4] = lambda[0] * x[0] * x[3]
+	values[5] = 2 * lambda[1]
+	values[6] = objFactor*(2*x[0]+x[1]+x[2]) + lambda[0]*x[1]*x[2]
+	values[7] = objFactor*x[0] + lambda[0]*x[0]*x[2]
+	values[8] = objFactor*x[0] + lambda[0]*x[0]*x[1]
+	values[9] = 2 * lambda[1]
+	return nil
+}
+
+// hs071BadScaling возвращает масштабы неверной длины.
+type hs071BadScaling struct{ hs071HessNLP }
+
+func (hs071BadScaling) Scaling() (float64, []float64, []float64) {
+	return 1, []float64{1, 1}, nil
+}
+
+// hs071EmptyHessian объявляет пустую структуру гессиана.
+type hs071EmptyHessian struct{ hs071HessNLP }
+
+func (hs071EmptyHessian) HessianStructure() ([]int32, []int32) { return nil, nil }
+
+// hs071EmptyJacobian объявляет пустую структуру якобиана при двух ограничениях.
+type hs071EmptyJacobian struct{ hs071NLP }
+
+func (hs071EmptyJacobian) JacobianStructure() ([]int32, []int32) { return nil, nil }
+
+func hs071NLPOptions(nlp NLP) ProblemOptions {
+	return ProblemOptions{
+		Variables:   [2][]float64{{1, 1, 1, 1}, {5, 5, 5, 5}},
+		Constraints: [2][]float64{{25, 40}, {2e19, 40}},
+		NLP:         nlp,
+	}
+}
+
+func TestNLP(t *testing.T) {
+	for name, nlp := range map[string]NLP{
+		"exact hessian":  hs071HessNLP{},
+		"limited memory": hs071NLP{},
+	} {
+		problem, err := NewProblem(hs071NLPOptions(nlp))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		problem.AddIntOption("print_level", 0)
+		res, err := problem.Optimize([]float64{1, 5, 5, 1})
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if math.Abs(res.Objective-17.014017) > 1e-4 {
+			t.Errorf("%s: objective = %v, want 17.014017", name, res.Objective)
+		}
+
+		// Без HessianProvider используется limited-memory
+		var opts bytes.Buffer
+		problem.WriteOptions(&opts)
+		_, hasH := nlp.(HessianProvider)
+		if lm := strings.Contains(opts.String(), "hessian_approximation limited-memory"); lm == hasH {
+			t.Errorf("%s: options:\n%s", name, opts.String())
+		}
+		problem.Close()
+	}
+}
+
+func TestNLPBadScaling(t *testing.T) {
+	problem, err := NewProblem(hs071NLPOptions(hs071BadScaling{}))
+	if err == nil {
+		problem.Close()
+		t.Fatal("expected error for short xScale")
+	}
+	if problem != nil {
+		t.Errorf("NewProblem returned a problem with error %v", err)
+	}
+}
+
+func TestNLPEmptyStructure(t *testing.T) {
+	for name, tt := range map[string]struct {
+		nlp  NLP
+		want string
+	}{
+		"hessian":  {hs071EmptyHessian{}, "Hessian structure"},
+		"jacobian": {hs071EmptyJacobian{}, "Jacobian structure"},
+	} {
+		problem, err := NewProblem(hs071NLPOptions(tt.nlp))
+		if err == nil {
+			problem.Close()
+			t.Errorf("%s: expected error", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error %q does not mention the %s", name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package ipopt
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+var (
+	ErrUnknownOption = errors.New("unknown option")
+	ErrOptionType    = errors.New("wrong option type")
+	ErrOptionValue   = errors.New("invalid option value")
+)
+
+// OptionError is returned when an option is rejected. Err wraps one of
+// ErrUnknownOption, ErrOptionType or ErrOptionValue.
+type OptionError struct {
+	Option string
+	Value  any
+	Err    error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("option %s=%v: %v", e.Option, e.Value, e.Err)
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// newOptionError explains why Ipopt rejected setting param to value, given
+// the type of option the setter expects.
+func newOptionError(param string, value any, want OptionType) error {
+	info, ok := LookupOption(param)
+	switch {
+	case !ok:
+		return &OptionError{Option: param, Value: value, Err: ErrUnknownOption}
+	case info.Type != want:
+		return &OptionError{Option: param, Value: value, Err: info.typeError(value)}
+	}
+	if err := info.Check(value); err != nil {
+		return err
+	}
+	return &OptionError{Option: param, Value: value, Err: ErrOptionValue}
+}
+
+// MuStrategy selects the barrier parameter update strategy (mu_strategy).
+type MuStrategy string
+
+const (
+	MuMonotone MuStrategy = "monotone"
+	MuAdaptive MuStrategy = "adaptive"
+)
+
+// LinearSolver selects the linear solver for the augmented system
+// (linear_solver). Only solvers Ipopt was built with are usable.
+type LinearSolver string
+
+const (
+	LinearSolverMA27       LinearSolver = "ma27"
+	LinearSolverMA57       LinearSolver = "ma57"
+	LinearSolverMA77       LinearSolver = "ma77"
+	LinearSolverMA86       LinearSolver = "ma86"
+	LinearSolverMA97       LinearSolver = "ma97"
+	LinearSolverPardiso    LinearSolver = "pardiso"
+	LinearSolverPardisoMKL LinearSolver = "pardisomkl"
+	LinearSolverSPRAL      LinearSolver = "spral"
+	LinearSolverWSMP       LinearSolver = "wsmp"
+	LinearSolverMUMPS      LinearSolver = "mumps"
+	LinearSolverCustom     LinearSolver = "custom"
+)
+
+// HessianApproximation selects how the Hessian of the Lagrangian is
+// obtained (hessian_approximation).
+type HessianApproximation string
+
+const (
+	HessianExact         HessianApproximation = "exact"
+	HessianLimitedMemory HessianApproximation = "limited-memory"
+)
+
+// NLPScalingMethod selects the problem scaling technique
+// (nlp_scaling_method).
+type NLPScalingMethod string
+
+const (
+	ScalingNone               NLPScalingMethod = "none"
+	ScalingUser               NLPScalingMethod = "user-scaling"
+	ScalingGradientBased      NLPScalingMethod = "gradient-based"
+	ScalingEquilibrationBased NLPScalingMethod = "equilibration-based"
+)
+
+// DerivativeTest selects the derivative checker run before the
+// optimization (derivative_test).
+type DerivativeTest string
+
+const (
+	DerivativeTestNone            DerivativeTest = "none"
+	DerivativeTestFirstOrder      DerivativeTest = "first-order"
+	DerivativeTestSecondOrder     DerivativeTest = "second-order"
+	DerivativeTestOnlySecondOrder DerivativeTest = "only-second-order"
+)
+
+// Options holds typed values for commonly used Ipopt options. Nil pointers
+// and empty strings leave the corresponding option at its current value, so
+// only the fields that are set are applied by SetOptions.
+type Options struct {
+	// Termination.
+	Tol            *float64 // tol: desired relative convergence tolerance
+	AcceptableTol  *float64 // acceptable_tol: tolerance of an acceptable point
+	AcceptableIter *int     // acceptable_iter: acceptable iterates before stopping
+	ConstrViolTol  *float64 // constr_viol_tol: absolute constraint violation
+	DualInfTol     *float64 // dual_inf_tol: absolute dual infeasibility
+	MaxIter        *int     // max_iter
+	MaxWallTime    *float64 // max_wall_time in seconds
+	MaxCPUTime     *float64 // max_cpu_time in seconds
+
+	// Barrier parameter.
+	MuStrategy MuStrategy
+	MuInit     *float64 // mu_init
+
+	// Linear algebra and Hessian.
+	LinearSolver            LinearSolver
+	HessianApproximation    HessianApproximation
+	LimitedMemoryMaxHistory *int // limited_memory_max_history
+
+	// Output.
+	PrintLevel     *int   // print_level, 0 to 12
+	OutputFile     string // output_file
+	FilePrintLevel *int   // file_print_level, 0 to 12
+	SuppressBanner *bool  // sb: skip the Ipopt banner
+
+	// Scaling.
+	NLPScalingMethod NLPScalingMethod
+	ObjScalingFactor *float64 // obj_scaling_factor
+
+	// Initialization.
+	BoundPush        *float64 // bound_push
+	BoundFrac        *float64 // bound_frac
+	BoundRelaxFactor *float64 // bound_relax_factor
+
+	// Warm start.
+	WarmStartInitPoint      *bool    // warm_start_init_point
+	WarmStartBoundPush      *float64 // warm_start_bound_push
+	WarmStartBoundFrac      *float64 // warm_start_bound_frac
+	WarmStartMultBoundPush  *float64 // warm_start_mult_bound_push
+	WarmStartSlackBoundPush *float64 // warm_start_slack_bound_push
+	WarmStartSlackBoundFrac *float64 // warm_start_slack_bound_frac
+
+	DerivativeTest DerivativeTest
+}
+
+// Float returns a pointer to v, for use in Options.
+func Float(v float64) *float64 { return &v }
+
+// Int returns a pointer to v, for use in Options.
+func Int(v int) *int { return &v }
+
+// Bool returns a pointer to v, for use in Options.
+func Bool(v bool) *bool { return &v }
+
+// Validate reports the first field of o whose value Ipopt would reject,
+// checking every set field against the option registry of this build.
+func (o *Options) Validate() error {
+	for _, e := range o.entries() {
+		info, ok := LookupOption(e.name)
+		if !ok {
+			return &OptionError{Option: e.name, Value: e.value, Err: ErrUnknownOption}
+		}
+		if err := info.Check(e.value); err != nil {
+			return err
+		}
+	}
+	// Ipopt registers obj_scaling_factor without bounds but cannot use 0.
+	if o.ObjScalingFactor != nil && *o.ObjScalingFactor == 0 {
+		return &OptionError{Option: "obj_scaling_factor", Value: 0.0, Err: fmt.Errorf("%w: must not be 0", ErrOptionValue)}
+	}
+	return nil
+}
+
+// SetOptions validates o and applies every field that is set. Nothing is
+// applied when validation fails.
+func (p *Problem) SetOptions(o Options) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	for _, e := range o.entries() {
+		if err := p.inner.setOption(e.name, e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entries lists the fields of o that are set, in declaration order, with
+// the option names and value types Ipopt uses.
+func (o *Options) entries() []optionEntry {
+	var b optionEntries
+	b.num("tol", o.Tol)
+	b.num("acceptable_tol", o.AcceptableTol)
+	b.int("acceptable_iter", o.AcceptableIter)
+	b.num("constr_viol_tol", o.ConstrViolTol)
+	b.num("dual_inf_tol", o.DualInfTol)
+	b.int("max_iter", o.MaxIter)
+	b.num("max_wall_time", o.MaxWallTime)
+	b.num("max_cpu_time", o.MaxCPUTime)
+	b.str("mu_strategy", string(o.MuStrategy))
+	b.num("mu_init", o.MuInit)
+	b.str("linear_solver", string(o.LinearSolver))
+	b.str("hessian_approximation", string(o.HessianApproximation))
+	b.int("limited_memory_max_history", o.LimitedMemoryMaxHistory)
+	b.int("print_level", o.PrintLevel)
+	b.str("output_file", o.OutputFile)
+	b.int("file_print_level", o.FilePrintLevel)
+	b.bool("sb", o.SuppressBanner)
+	b.str("nlp_scaling_method", string(o.NLPScalingMethod))
+	b.num("obj_scaling_factor", o.ObjScalingFactor)
+	b.num("bound_push", o.BoundPush)
+	b.num("bound_frac", o.BoundFrac)
+	b.num("bound_relax_factor", o.BoundRelaxFactor)
+	b.bool("warm_start_init_point", o.WarmStartInitPoint)
+	b.num("warm_start_bound_push", o.WarmStartBoundPush)
+	b.num("warm_start_bound_frac", o.WarmStartBoundFrac)
+	b.num("warm_start_mult_bound_push", o.WarmStartMultBoundPush)
+	b.num("warm_start_slack_bound_push", o.WarmStartSlackBoundPush)
+	b.num("warm_start_slack_bound_frac", o.WarmStartSlackBoundFrac)
+	b.str("derivative_test", string(o.DerivativeTest))
+	return b
+}
+
+// optionEntries collects the options of the fields that are set.
+type optionEntries []optionEntry
+
+func (b *optionEntries) num(name string, v *float64) {
+	if v != nil {
+		*b = append(*b, optionEntry{name: name, value: *v})
+	}
+}
+
+func (b *optionEntries) int(name string, v *int) {
+	if v != nil {
+		*b = append(*b, optionEntry{name: name, value: *v})
+	}
+}
+
+func (b *optionEntries) str(name string, v string) {
+	if v != "" {
+		*b = append(*b, optionEntry{name: name, value: v})
+	}
+}
+
+func (b *optionEntries) bool(name string, v *bool) {
+	if v == nil {
+		return
+	}
+	if *v {
+		b.str(name, "yes")
+	} else {
+		b.str(name, "no")
+	}
+}
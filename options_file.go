@@ -0,0 +1,227 @@
+package ipopt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LoadOptionsFile applies the options in the ipopt.opt-style file at path,
+// see ReadOptions.
+func (p *Problem) LoadOptionsFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := p.ReadOptions(f); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadOptions applies options in the format of Ipopt's ipopt.opt file:
+// whitespace separated pairs of name and value, with comments starting at
+// '#' and values containing spaces enclosed in double quotes. The whole
+// input is parsed before any option is applied.
+func (p *Problem) ReadOptions(r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	entries, err := parseOptions(string(data))
+	if err != nil {
+		return err
+	}
+
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	for _, e := range entries {
+		if err := p.inner.setOption(e.name, e.value); err != nil {
+			return fmt.Errorf("line %d: %w", e.line, err)
+		}
+	}
+	return nil
+}
+
+// SaveOptionsFile writes the options set on the problem to path, see
+// WriteOptions.
+func (p *Problem) SaveOptionsFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := p.WriteOptions(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteOptions writes the options set on the problem, sorted by name, in the
+// format read by ReadOptions and by Ipopt itself. Options set internally,
+// such as hessian_approximation for problems without EvalH, are included.
+func (p *Problem) WriteOptions(w io.Writer) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	names := make([]string, 0, len(p.inner.options))
+	for name := range p.inner.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fmt.Fprintf(bw, "%s %s\n", name, formatOptionValue(p.inner.options[name]))
+	}
+	return bw.Flush()
+}
+
+func formatOptionValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		if v == "" || strings.ContainsAny(v, " \t\n#") {
+			return `"` + v + `"`
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+type optionEntry struct {
+	name  string
+	value any
+	line  int
+}
+
+// parseOptions splits an options file into entries, converting every value
+// to the type Ipopt registered the option with.
+func parseOptions(data string) ([]optionEntry, error) {
+	var entries []optionEntry
+
+	t := optionTokenizer{data: data, line: 1}
+	for {
+		name, line, ok := t.next()
+		if !ok {
+			return entries, nil
+		}
+		raw, _, ok := t.next()
+		if !ok {
+			return nil, fmt.Errorf("line %d: option %s has no value", line, name)
+		}
+
+		value, err := parseOptionValue(name, raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, optionEntry{name: name, value: value, line: line})
+	}
+}
+
+// parseOptionValue converts raw and checks it against the registry, so that
+// a file with an out-of-range value is rejected before anything is applied.
+func parseOptionValue(name string, raw string) (any, error) {
+	info, ok := LookupOption(name)
+	if !ok {
+		return nil, &OptionError{Option: name, Value: raw, Err: ErrUnknownOption}
+	}
+
+	var value any = raw
+	switch info.Type {
+	case OptionNumber:
+		// Ipopt accepts Fortran style exponents such as 1d-8.
+		v, err := strconv.ParseFloat(strings.NewReplacer("d", "e", "D", "e").Replace(raw), 64)
+		if err != nil {
+			return nil, &OptionError{Option: name, Value: raw, Err: fmt.Errorf("%w: not a number", ErrOptionValue)}
+		}
+		value = v
+	case OptionInteger:
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, &OptionError{Option: name, Value: raw, Err: fmt.Errorf("%w: not an integer", ErrOptionValue)}
+		}
+		value = v
+	}
+	if err := info.Check(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// optionTokenizer reads tokens the way Ipopt reads its options file.
+type optionTokenizer struct {
+	data string
+	pos  int
+	line int
+}
+
+// next returns the next token and the line it starts on.
+func (t *optionTokenizer) next() (string, int, bool) {
+	for t.pos < len(t.data) {
+		c := t.data[t.pos]
+		if c == '#' {
+			for t.pos < len(t.data) && t.data[t.pos] != '\n' {
+				t.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			break
+		}
+		if c == '\n' {
+			t.line++
+		}
+		t.pos++
+	}
+	if t.pos == len(t.data) {
+		return "", t.line, false
+	}
+
+	line := t.line
+	var b strings.Builder
+	quoted := false
+	if t.data[t.pos] == '"' {
+		quoted = true
+		t.pos++
+	}
+	for t.pos < len(t.data) {
+		c := t.data[t.pos]
+		if quoted {
+			if c == '"' {
+				t.pos++
+				break
+			}
+		} else if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			break
+		}
+		if c == '\n' {
+			t.line++
+		}
+		b.WriteByte(c)
+		t.pos++
+	}
+	return b.String(), line, true
+}
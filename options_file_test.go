@@ -0,0 +1,90 @@
+package ipopt
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOptionTokenizer(t *testing.T) {
+	data := "# tuned for family A\ntol 1d-8 # comment\n\nmu_strategy   adaptive\noutput_file \"run 1.out\"\n"
+	want := []string{"tol", "1d-8", "mu_strategy", "adaptive", "output_file", "run 1.out"}
+	wantLines := []int{2, 2, 4, 4, 5, 5}
+
+	tok := optionTokenizer{data: data, line: 1}
+	var got []string
+	var lines []int
+	for {
+		s, line, ok := tok.next()
+		if !ok {
+			break
+		}
+		got = append(got, s)
+		lines = append(lines, line)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokens = %q, want %q", got, want)
+	}
+	if !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("lines = %v, want %v", lines, wantLines)
+	}
+}
+
+func TestOptionsFile(t *testing.T) {
+	p := &MyProblem{}
+	problem, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+
+	err = problem.ReadOptions(strings.NewReader("tol 1d-10\nmax_iter 50\nmu_strategy adaptive\nprint_level 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ipopt.opt")
+	if err := problem.SaveOptionsFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := NewProblem(hs071Options(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := other.LoadOptionsFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var a, b bytes.Buffer
+	problem.WriteOptions(&a)
+	other.WriteOptions(&b)
+	want := "max_iter 50\nmu_strategy adaptive\nprint_level 0\ntol 1e-10\n"
+	if a.String() != want || b.String() != want {
+		t.Errorf("options = %q and %q, want %q", a.String(), b.String(), want)
+	}
+
+	err = problem.ReadOptions(strings.NewReader("tol 1e-8\nmu_stratgy adaptive\n"))
+	if !errors.Is(err, ErrUnknownOption) {
+		t.Errorf("got %v, want ErrUnknownOption", err)
+	}
+	err = problem.ReadOptions(strings.NewReader("max_iter many\n"))
+	if !errors.Is(err, ErrOptionValue) {
+		t.Errorf("got %v, want ErrOptionValue", err)
+	}
+
+	// Значение вне диапазона отклоняется до применения всего файла
+	err = problem.ReadOptions(strings.NewReader("max_iter 10\nprint_level 13\n"))
+	if !errors.Is(err, ErrOptionValue) {
+		t.Errorf("got %v, want ErrOptionValue", err)
+	}
+	a.Reset()
+	problem.WriteOptions(&a)
+	if a.String() != want {
+		t.Errorf("options after failed read = %q, want %q", a.String(), want)
+	}
+}
@@ -0,0 +1,57 @@
+package ipopt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		opt   Options
+		valid bool
+	}{
+		{"empty", Options{}, true},
+		{"typical", Options{
+			Tol:                  Float(1e-8),
+			MaxIter:              Int(100),
+			PrintLevel:           Int(0),
+			MuStrategy:           MuAdaptive,
+			HessianApproximation: HessianLimitedMemory,
+			WarmStartInitPoint:   Bool(true),
+		}, true},
+		{"negative tol", Options{Tol: Float(-1)}, false},
+		{"negative max_iter", Options{MaxIter: Int(-1)}, false},
+		{"print_level", Options{PrintLevel: Int(13)}, false},
+		{"bound_frac", Options{BoundFrac: Float(0.6)}, false},
+		{"mu_strategy", Options{MuStrategy: "mu_stratgy"}, false},
+		{"linear_solver", Options{LinearSolver: "ma42"}, false},
+		{"obj_scaling_factor", Options{ObjScalingFactor: Float(0)}, false},
+	}
+
+	for _, tt := range tests {
+		err := tt.opt.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrOptionValue) {
+			t.Errorf("%s: got %v, want ErrOptionValue", tt.name, err)
+		}
+	}
+}
+
+func TestOptionsValidateRegistry(t *testing.T) {
+	info, ok := LookupOption("linear_solver")
+	if !ok {
+		t.Fatal("linear_solver is not registered")
+	}
+
+	// Validate принимает ровно те решатели, что есть в этой сборке
+	for _, s := range []LinearSolver{LinearSolverMA27, LinearSolverMA57, LinearSolverMUMPS, LinearSolverSPRAL} {
+		want := info.Check(string(s))
+		got := (&Options{LinearSolver: s}).Validate()
+		if (got == nil) != (want == nil) {
+			t.Errorf("%s: Validate = %v, registry = %v", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package ipopt
+
+// #include <stdlib.h>
+// #include "ipopt_c_api.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"unsafe"
+)
+
+// SetOutput sends everything Ipopt prints for this problem to w instead of
+// stdout, and sets print_level to level. A nil w restores stdout. Writes
+// happen on the goroutine that called Solve, while the problem is locked.
+//
+// SetOutput replaces any file opened with OpenOutputFile, so files should be
+// opened after it.
+func (p *Problem) SetOutput(w io.Writer, level int) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+	if err := p.inner.addIntOption("print_level", level); err != nil {
+		return err
+	}
+
+	var userData C.uintptr_t
+	if w != nil {
+		userData = C.uintptr_t(p.inner.handle)
+	}
+	p.inner.cb.output = w
+	if !C.ipopt_problem_set_output(p.inner.problem, userData) {
+		return errors.New("cannot redirect output")
+	}
+	return nil
+}
+
+// OpenOutputFile makes Ipopt write its output with the given print level to
+// the file at path, in addition to the console.
+func (p *Problem) OpenOutputFile(path string, level int) error {
+	if err := ValidateOptions(map[string]any{"file_print_level": level}); err != nil {
+		return err
+	}
+
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	if !C.ipopt_problem_open_output_file(p.inner.problem, cpath, C.int(level)) {
+		return fmt.Errorf("cannot open output file %s", path)
+	}
+	return nil
+}
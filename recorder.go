@@ -0,0 +1,163 @@
+package ipopt
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// iterationObserver receives every iteration alongside the intermediate
+// callback, without being able to stop the solve.
+type iterationObserver interface {
+	observeIteration(it Iteration)
+}
+
+// Recorder keeps the history of iterations of the problems it is attached
+// to. Iterations of consecutive solves accumulate until Reset. A Recorder
+// may be read while a solve is running.
+type Recorder struct {
+	mu    sync.Mutex
+	iters []Iteration
+}
+
+// NewRecorder returns a Recorder attached to p.
+func NewRecorder(p *Problem) (*Recorder, error) {
+	r := &Recorder{}
+	if err := r.Attach(p); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Attach starts recording the iterations of p. It does not replace the
+// intermediate callback of p.
+func (r *Recorder) Attach(p *Problem) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	for _, o := range p.inner.cb.observers {
+		if o == r {
+			return nil
+		}
+	}
+	p.inner.cb.observers = append(p.inner.cb.observers, r)
+	return nil
+}
+
+// Detach stops recording the iterations of p.
+func (r *Recorder) Detach(p *Problem) error {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return ErrProblemClosed
+	}
+
+	obs := p.inner.cb.observers[:0]
+	for _, o := range p.inner.cb.observers {
+		if o != r {
+			obs = append(obs, o)
+		}
+	}
+	p.inner.cb.observers = obs
+	return nil
+}
+
+func (r *Recorder) observeIteration(it Iteration) {
+	r.mu.Lock()
+	r.iters = append(r.iters, it)
+	r.mu.Unlock()
+}
+
+// Iterations returns a copy of the recorded iterations.
+func (r *Recorder) Iterations() []Iteration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Iteration(nil), r.iters...)
+}
+
+// Reset discards the recorded iterations.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.iters = nil
+	r.mu.Unlock()
+}
+
+var recorderColumns = []string{
+	"iter", "mode", "objective", "inf_pr", "inf_du", "mu", "d_norm",
+	"regularization", "alpha_du", "alpha_pr", "ls_trials",
+}
+
+// WriteCSV writes the recorded iterations as CSV with a header row.
+func (r *Recorder) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(recorderColumns); err != nil {
+		return err
+	}
+
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for _, it := range r.Iterations() {
+		err := cw.Write([]string{
+			strconv.Itoa(it.Iter), it.Mode.String(), f(it.ObjValue), f(it.InfPr),
+			f(it.InfDu), f(it.Mu), f(it.DNorm), f(it.RegularizationSize),
+			f(it.AlphaDu), f(it.AlphaPr), strconv.Itoa(it.LsTrials),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// iterationRecord is the JSON form of an Iteration, using the same names as
+// the CSV columns.
+type iterationRecord struct {
+	Iter           int     `json:"iter"`
+	Mode           string  `json:"mode"`
+	Objective      float64 `json:"objective"`
+	InfPr          float64 `json:"inf_pr"`
+	InfDu          float64 `json:"inf_du"`
+	Mu             float64 `json:"mu"`
+	DNorm          float64 `json:"d_norm"`
+	Regularization float64 `json:"regularization"`
+	AlphaDu        float64 `json:"alpha_du"`
+	AlphaPr        float64 `json:"alpha_pr"`
+	LsTrials       int     `json:"ls_trials"`
+}
+
+// WriteJSONLines writes the recorded iterations as one JSON object per line.
+func (r *Recorder) WriteJSONLines(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, it := range r.Iterations() {
+		err := enc.Encode(iterationRecord{
+			Iter:           it.Iter,
+			Mode:           it.Mode.String(),
+			Objective:      it.ObjValue,
+			InfPr:          it.InfPr,
+			InfDu:          it.InfDu,
+			Mu:             it.Mu,
+			DNorm:          it.DNorm,
+			Regularization: it.RegularizationSize,
+			AlphaDu:        it.AlphaDu,
+			AlphaPr:        it.AlphaPr,
+			LsTrials:       it.LsTrials,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,94 @@
+package ipopt
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+	calls := 0
+	opt.Intermediate = func(it Iteration) bool {
+		calls++
+		return true
+	}
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+	problem.AddIntOption("print_level", 0)
+
+	r, err := NewRecorder(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := problem.Optimize([]float64{1, 5, 5, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iters := r.Iterations()
+	if len(iters) != res.Iterations+1 || calls != len(iters) {
+		t.Fatalf("recorded %d iterations, callback called %d times, want %d", len(iters), calls, res.Iterations+1)
+	}
+	if iters[len(iters)-1].ObjValue != res.Objective {
+		t.Errorf("last objective = %v, want %v", iters[len(iters)-1].ObjValue, res.Objective)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(iters)+1 || rows[0][0] != "iter" || rows[1][1] != "regular" {
+		t.Errorf("csv = %v", rows)
+	}
+
+	buf.Reset()
+	if err := r.WriteJSONLines(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&buf)
+	n := 0
+	for dec.More() {
+		var rec iterationRecord
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Iter != iters[n].Iter || rec.Mu != iters[n].Mu {
+			t.Errorf("record %d = %+v, want %+v", n, rec, iters[n])
+		}
+		n++
+	}
+	if n != len(iters) {
+		t.Errorf("%d json lines, want %d", n, len(iters))
+	}
+
+	// После Detach новые итерации не записываются
+	if err := r.Detach(problem); err != nil {
+		t.Fatal(err)
+	}
+	r.Reset()
+	if _, err := problem.Optimize([]float64{1, 5, 5, 1}); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Iterations()) != 0 {
+		t.Errorf("recorded %d iterations after Detach", len(r.Iterations()))
+	}
+
+	problem.Close()
+	if err := r.Attach(problem); !errors.Is(err, ErrProblemClosed) {
+		t.Errorf("Attach after Close: got %v, want ErrProblemClosed", err)
+	}
+	if err := r.Detach(problem); !errors.Is(err, ErrProblemClosed) {
+		t.Errorf("Detach after Close: got %v, want ErrProblemClosed", err)
+	}
+}
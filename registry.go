@@ -0,0 +1,217 @@
+package ipopt
+
+// #include "ipopt_c_api.h"
+import "C"
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OptionType is the type of value an Ipopt option takes.
+type OptionType int
+
+const (
+	OptionNumber  OptionType = C.ipopt_option_number
+	OptionInteger OptionType = C.ipopt_option_integer
+	OptionString  OptionType = C.ipopt_option_string
+)
+
+func (t OptionType) String() string {
+	switch t {
+	case OptionNumber:
+		return "number"
+	case OptionInteger:
+		return "integer"
+	case OptionString:
+		return "string"
+	}
+	return "unknown"
+}
+
+// OptionInfo describes an option registered with Ipopt.
+type OptionInfo struct {
+	Name             string
+	Type             OptionType
+	Category         string
+	ShortDescription string
+	LongDescription  string
+	Advanced         bool
+
+	// Default is a float64, an int or a string depending on Type.
+	Default any
+
+	// Lower and Upper bound numeric and integer options and are infinite
+	// when the option is unbounded on that side. The strict flags mark
+	// bounds that are excluded from the valid range.
+	Lower, Upper             float64
+	LowerStrict, UpperStrict bool
+
+	// Values lists the settings of a string option. A single "*" entry
+	// means any string is accepted.
+	Values []OptionValue
+}
+
+// OptionValue is one valid setting of a string option.
+type OptionValue struct {
+	Value       string
+	Description string
+}
+
+var registeredOptions = sync.OnceValue(func() []OptionInfo {
+	n := int(C.ipopt_option_count())
+	opts := make([]OptionInfo, 0, n)
+	for i := 0; i < n; i++ {
+		var info C.ipopt_option_info
+		if !C.ipopt_option_get(C.int(i), &info) {
+			continue
+		}
+
+		o := OptionInfo{
+			Name:             C.GoString(info.name),
+			Type:             OptionType(info._type),
+			Category:         C.GoString(info.category),
+			ShortDescription: C.GoString(info.short_description),
+			LongDescription:  C.GoString(info.long_description),
+			Advanced:         bool(info.advanced),
+			Lower:            math.Inf(-1),
+			Upper:            math.Inf(1),
+		}
+		if info.has_lower {
+			o.Lower = float64(info.lower)
+			o.LowerStrict = bool(info.lower_strict)
+		}
+		if info.has_upper {
+			o.Upper = float64(info.upper)
+			o.UpperStrict = bool(info.upper_strict)
+		}
+
+		switch o.Type {
+		case OptionNumber:
+			o.Default = float64(info.default_number)
+		case OptionInteger:
+			o.Default = int(info.default_integer)
+		case OptionString:
+			o.Default = C.GoString(info.default_string)
+			for j := 0; j < int(info.num_values); j++ {
+				var value, desc *C.char
+				if C.ipopt_option_get_value(C.int(i), C.int(j), &value, &desc) {
+					o.Values = append(o.Values, OptionValue{C.GoString(value), C.GoString(desc)})
+				}
+			}
+		}
+		opts = append(opts, o)
+	}
+	return opts
+})
+
+// RegisteredOptions returns every option Ipopt knows about, sorted by name.
+func RegisteredOptions() []OptionInfo {
+	return append([]OptionInfo(nil), registeredOptions()...)
+}
+
+// LookupOption returns the description of the named option.
+func LookupOption(name string) (OptionInfo, bool) {
+	opts := registeredOptions()
+	i := sort.Search(len(opts), func(i int) bool { return opts[i].Name >= name })
+	if i < len(opts) && opts[i].Name == name {
+		return opts[i], true
+	}
+	return OptionInfo{}, false
+}
+
+// ValidateOptions checks a set of options against the registry without a
+// problem, in the order of their names. Values must be float64 for numeric
+// options, int for integer options and string for string options.
+func ValidateOptions(opts map[string]any) error {
+	names := make([]string, 0, len(opts))
+	for name := range opts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		info, ok := LookupOption(name)
+		if !ok {
+			return &OptionError{Option: name, Value: opts[name], Err: ErrUnknownOption}
+		}
+		if err := info.Check(opts[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether Ipopt would accept value for the option.
+func (o OptionInfo) Check(value any) error {
+	var err error
+	switch o.Type {
+	case OptionNumber:
+		switch v := value.(type) {
+		case float64:
+			err = o.checkRange(v)
+		case int:
+			err = o.checkRange(float64(v))
+		default:
+			err = o.typeError(value)
+		}
+	case OptionInteger:
+		if v, ok := value.(int); ok {
+			err = o.checkRange(float64(v))
+		} else {
+			err = o.typeError(value)
+		}
+	case OptionString:
+		if v, ok := value.(string); ok {
+			err = o.checkString(v)
+		} else {
+			err = o.typeError(value)
+		}
+	}
+	if err != nil {
+		return &OptionError{Option: o.Name, Value: value, Err: err}
+	}
+	return nil
+}
+
+func (o OptionInfo) typeError(value any) error {
+	return fmt.Errorf("%w: %s is a %s option, got %T", ErrOptionType, o.Name, o.Type, value)
+}
+
+func (o OptionInfo) checkRange(v float64) error {
+	if v < o.Lower || (o.LowerStrict && v == o.Lower) ||
+		v > o.Upper || (o.UpperStrict && v == o.Upper) {
+		return fmt.Errorf("%w: out of range %s", ErrOptionValue, o.rangeString())
+	}
+	return nil
+}
+
+func (o OptionInfo) rangeString() string {
+	lo, hi := "[", "]"
+	if o.LowerStrict || math.IsInf(o.Lower, -1) {
+		lo = "("
+	}
+	if o.UpperStrict || math.IsInf(o.Upper, 1) {
+		hi = ")"
+	}
+	return fmt.Sprintf("%s%v, %v%s", lo, o.Lower, o.Upper, hi)
+}
+
+func (o OptionInfo) checkString(v string) error {
+	for _, s := range o.Values {
+		if s.Value == "*" || strings.EqualFold(s.Value, v) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q is not one of %s", ErrOptionValue, v, o.valueList())
+}
+
+func (o OptionInfo) valueList() string {
+	values := make([]string, len(o.Values))
+	for i, s := range o.Values {
+		values[i] = s.Value
+	}
+	return strings.Join(values, ", ")
+}
@@ -0,0 +1,61 @@
+package ipopt
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestRegisteredOptions(t *testing.T) {
+	opts := RegisteredOptions()
+	if len(opts) < 100 {
+		t.Fatalf("got %d options", len(opts))
+	}
+	if !sort.SliceIsSorted(opts, func(i, j int) bool { return opts[i].Name < opts[j].Name }) {
+		t.Error("options are not sorted by name")
+	}
+
+	tol, ok := LookupOption("tol")
+	if !ok {
+		t.Fatal("tol not found")
+	}
+	if tol.Type != OptionNumber || tol.Default != 1e-8 || tol.Lower != 0 || !tol.LowerStrict {
+		t.Errorf("tol = %+v", tol)
+	}
+
+	pl, ok := LookupOption("print_level")
+	if !ok || pl.Type != OptionInteger || pl.Lower != 0 || pl.Upper != 12 {
+		t.Errorf("print_level = %+v", pl)
+	}
+
+	mu, ok := LookupOption("mu_strategy")
+	if !ok || mu.Type != OptionString || mu.Default != "monotone" || len(mu.Values) != 2 {
+		t.Errorf("mu_strategy = %+v", mu)
+	}
+
+	if _, ok := LookupOption("mu_stratgy"); ok {
+		t.Error("found misspelled option")
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts map[string]any
+		want error
+	}{
+		{"valid", map[string]any{"tol": 1e-10, "max_iter": 100, "mu_strategy": "Adaptive"}, nil},
+		{"unknown", map[string]any{"mu_stratgy": "adaptive"}, ErrUnknownOption},
+		{"type", map[string]any{"max_iter": 1.5}, ErrOptionType},
+		{"range", map[string]any{"print_level": 13}, ErrOptionValue},
+		{"strict bound", map[string]any{"tol": 0.0}, ErrOptionValue},
+		{"value", map[string]any{"mu_strategy": "fast"}, ErrOptionValue},
+	}
+
+	for _, tt := range tests {
+		err := ValidateOptions(tt.opts)
+		if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package ipopt
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// Result is the outcome of Optimize.
+type Result struct {
+	X          []float64
+	G          []float64
+	Objective  float64
+	MultG      []float64
+	MultXL     []float64
+	MultXU     []float64
+	Status     Status
+	Iterations int
+	Duration   time.Duration
+	Stats      Stats
+}
+
+// Optimize solves the problem from the starting point x0, which is left
+// unmodified.
+func (p *Problem) Optimize(x0 []float64) (*Result, error) {
+	return p.OptimizeContext(context.Background(), x0)
+}
+
+// OptimizeContext is like Optimize but honours cancellation and deadlines of
+// ctx the same way SolveContext does. The result is returned whenever Ipopt
+// ran, even if the error is non-nil; the error is nil when the problem was
+// solved, to optimality or to an acceptable level.
+func (p *Problem) OptimizeContext(ctx context.Context, x0 []float64) (*Result, error) {
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return nil, ErrProblemClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(x0) != p.inner.n {
+		return nil, fmt.Errorf("x0 has %d elements, want %d", len(x0), p.inner.n)
+	}
+	if err := p.inner.checkCallbackStructures(); err != nil {
+		return nil, err
+	}
+
+	n, m := p.inner.n, p.inner.m
+
+	res := &Result{
+		X:      make([]float64, n),
+		G:      make([]float64, m),
+		MultG:  make([]float64, m),
+		MultXL: make([]float64, n),
+		MultXU: make([]float64, n),
+	}
+	copy(res.X, x0)
+	return p.optimize(ctx, res)
+}
+
+// optimize runs Ipopt from the point and multipliers in res and fills in
+// the rest of res.
+func (p *Problem) optimize(ctx context.Context, res *Result) (*Result, error) {
+	objVal := []float64{0}
+
+	start := time.Now()
+	ret, err := p.solve(ctx, res.X, res.G, objVal, res.MultG, res.MultXL, res.MultXU)
+
+	res.Duration = time.Since(start)
+	res.Objective = objVal[0]
+	res.Status = ret
+	res.Iterations = p.inner.cb.iter
+	res.Stats = p.inner.statistics()
+
+	if err != nil {
+		return res, err
+	}
+	if res.Status.Succeeded() || res.Status.Acceptable() {
+		return res, nil
+	}
+	return res, res.Status.Err()
+}
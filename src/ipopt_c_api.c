@@ -1,43 +1,45 @@
 #include "ipopt_c_api.h"
-#include "IpStdCInterface.h"
+#include "ipopt_problem.h"
 #include <stdlib.h>
 
-struct _ipopt_problem_t {
-  IpoptProblem problem;
-};
-
 ipopt_problem_t *
-ipopt_problem_create(int n, double *xL, double *xU, double m, double *gl, double *gu,
+ipopt_problem_create(int n, double *xL, double *xU, int m, double *gl, double *gu,
                      int nnzj, int nnzh, eval_f_cb eval_f,
                      eval_grad_f_cb eval_grad_f, eval_g_cb eval_g,
                      eval_jac_g_cb eval_jac_g, eval_h_cb eval_h) {
   IpoptProblem problem =
       CreateIpoptProblem(n, xL, xU, m, gl, gu, nnzj, nnzh, 0, eval_f, eval_g,
                          eval_grad_f, eval_jac_g, eval_h);
+  if (problem == NULL) {
+    return NULL;
+  }
   ipopt_problem_t *ret = (ipopt_problem_t *)malloc(sizeof(ipopt_problem_t));
   ret->problem = problem;
   return ret;
 }
 
-void ipopt_problem_add_str_option(ipopt_problem_t *p, const char *param,
+bool ipopt_problem_add_str_option(ipopt_problem_t *p, const char *param,
                                   const char *value) {
   if (p->problem != NULL) {
-    AddIpoptStrOption(p->problem, (char *)param, (char *)value);
+    return AddIpoptStrOption(p->problem, (char *)param, (char *)value);
   }
+  return false;
 }
 
-void ipopt_problem_add_int_option(ipopt_problem_t *p, const char *param,
+bool ipopt_problem_add_int_option(ipopt_problem_t *p, const char *param,
                                   int value) {
   if (p->problem != NULL) {
-    AddIpoptIntOption(p->problem, (char *)param, value);
+    return AddIpoptIntOption(p->problem, (char *)param, value);
   }
+  return false;
 }
 
-void ipopt_problem_add_num_option(ipopt_problem_t *p, const char *param,
+bool ipopt_problem_add_num_option(ipopt_problem_t *p, const char *param,
                                   double value) {
   if (p->problem != NULL) {
-    AddIpoptNumOption(p->problem, (char *)param, value);
+    return AddIpoptNumOption(p->problem, (char *)param, value);
   }
+  return false;
 }
 
 void ipopt_problem_set_problem_scaling(ipopt_problem_t *p, double obj_scaling,
@@ -47,18 +49,59 @@ void ipopt_problem_set_problem_scaling(ipopt_problem_t *p, double obj_scaling,
   }
 }
 
+bool ipopt_problem_open_output_file(ipopt_problem_t *p, const char *file_name,
+                                    int print_level) {
+  if (p->problem != NULL) {
+    return OpenIpoptOutputFile(p->problem, (char *)file_name, print_level);
+  }
+  return false;
+}
+
+void ipopt_problem_set_intermediate_callback(ipopt_problem_t *p,
+                                             intermediate_cb intermediate) {
+  if (p->problem != NULL) {
+    SetIntermediateCallback(p->problem, intermediate);
+  }
+}
+
 enum ipopt_return_status ipopt_problem_solve(ipopt_problem_t *p, double *x,
                                              double *g, double *obj_val,
                                              double *mult_g, double *mult_x_L,
-                                             double *mult_x_U, char *user_data) {
+                                             double *mult_x_U,
+                                             uintptr_t user_data) {
   if (p->problem != NULL) {
-    enum ApplicationReturnStatus ret = IpoptSolve(
-        p->problem, x, g, obj_val, mult_g, mult_x_L, mult_x_U, user_data);
+    enum ApplicationReturnStatus ret =
+        IpoptSolve(p->problem, x, g, obj_val, mult_g, mult_x_L, mult_x_U,
+                   (void *)user_data);
     return ret;
   }
   return Internal_Error;
 }
 
+bool ipopt_problem_get_current_iterate(ipopt_problem_t *p, bool scaled, int n,
+                                       double *x, double *z_L, double *z_U,
+                                       int m, double *g, double *lambda) {
+  if (p->problem != NULL) {
+    return GetIpoptCurrentIterate(p->problem, scaled, n, x, z_L, z_U, m, g,
+                                  lambda);
+  }
+  return false;
+}
+
+bool ipopt_problem_get_current_violations(
+    ipopt_problem_t *p, bool scaled, int n, double *x_L_violation,
+    double *x_U_violation, double *compl_x_L, double *compl_x_U,
+    double *grad_lag_x, int m, double *nlp_constraint_violation,
+    double *compl_g) {
+  if (p->problem != NULL) {
+    return GetIpoptCurrentViolations(p->problem, scaled, n, x_L_violation,
+                                     x_U_violation, compl_x_L, compl_x_U,
+                                     grad_lag_x, m, nlp_constraint_violation,
+                                     compl_g);
+  }
+  return false;
+}
+
 void ipopt_problem_free(ipopt_problem_t *p) {
   if (p->problem != NULL) {
     FreeIpoptProblem(p->problem);
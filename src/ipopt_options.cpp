@@ -0,0 +1,158 @@
+#include "ipopt_c_api.h"
+
+#include "IpIpoptApplication.hpp"
+#include "IpRegOptions.hpp"
+
+#include <map>
+#include <string>
+#include <utility>
+#include <vector>
+
+using namespace Ipopt;
+
+// registry returns the options Ipopt knows about. It is built once and
+// shared, as registering all options is fairly expensive.
+static const SmartPtr<RegisteredOptions> &registry() {
+  static const SmartPtr<RegisteredOptions> reg = [] {
+    SmartPtr<RegisteredOptions> r = new RegisteredOptions();
+    IpoptApplication::RegisterAllIpoptOptions(r);
+    return r;
+  }();
+  return reg;
+}
+
+static int option_type(RegisteredOptionType type) {
+  switch (type) {
+  case OT_Number:
+    return ipopt_option_number;
+  case OT_Integer:
+    return ipopt_option_integer;
+  case OT_String:
+    return ipopt_option_string;
+  default:
+    return ipopt_option_unknown;
+  }
+}
+
+namespace {
+
+// option_entry owns the strings handed out through ipopt_option_info.
+struct option_entry {
+  std::string name;
+  std::string category;
+  std::string short_description;
+  std::string long_description;
+  std::string default_string;
+  std::vector<std::pair<std::string, std::string>> values;
+  ipopt_option_info info;
+};
+
+} // namespace
+
+// catalogue returns all registered options sorted by name.
+static const std::vector<option_entry> &catalogue() {
+  static const std::vector<option_entry> entries = [] {
+    const SmartPtr<RegisteredOptions> &reg = registry();
+
+    // Options may be registered outside of any category, so the category
+    // is looked up from the category side.
+    std::map<std::string, std::string> categories;
+    for (const auto &c : reg->RegisteredCategories()) {
+      for (const auto &opt : c.second->RegisteredOptions()) {
+        categories[opt->Name()] = c.second->Name();
+      }
+    }
+
+    std::vector<option_entry> v;
+    for (const auto &o : reg->RegisteredOptionsList()) {
+      const SmartPtr<RegisteredOption> &opt = o.second;
+      option_entry e;
+      e.name = opt->Name();
+      e.category = categories[opt->Name()];
+      e.short_description = opt->ShortDescription();
+      e.long_description = opt->LongDescription();
+
+      ipopt_option_info &info = e.info;
+      info.type = option_type(opt->Type());
+      info.advanced = opt->Advanced();
+      info.default_number = 0;
+      info.default_integer = 0;
+      info.has_lower = false;
+      info.lower_strict = false;
+      info.lower = 0;
+      info.has_upper = false;
+      info.upper_strict = false;
+      info.upper = 0;
+
+      switch (opt->Type()) {
+      case OT_Number:
+        info.default_number = opt->DefaultNumber();
+        info.has_lower = opt->HasLower();
+        if (info.has_lower) {
+          info.lower_strict = opt->LowerStrict();
+          info.lower = opt->LowerNumber();
+        }
+        info.has_upper = opt->HasUpper();
+        if (info.has_upper) {
+          info.upper_strict = opt->UpperStrict();
+          info.upper = opt->UpperNumber();
+        }
+        break;
+      case OT_Integer:
+        info.default_integer = opt->DefaultInteger();
+        info.has_lower = opt->HasLower();
+        if (info.has_lower) {
+          info.lower = opt->LowerInteger();
+        }
+        info.has_upper = opt->HasUpper();
+        if (info.has_upper) {
+          info.upper = opt->UpperInteger();
+        }
+        break;
+      case OT_String:
+        e.default_string = opt->DefaultString();
+        for (const auto &s : opt->GetValidStrings()) {
+          e.values.emplace_back(s.value_, s.description_);
+        }
+        break;
+      default:
+        break;
+      }
+      v.push_back(std::move(e));
+    }
+
+    // The strings do not move any more once the vector is complete.
+    for (auto &e : v) {
+      e.info.name = e.name.c_str();
+      e.info.category = e.category.c_str();
+      e.info.short_description = e.short_description.c_str();
+      e.info.long_description = e.long_description.c_str();
+      e.info.default_string = e.default_string.c_str();
+      e.info.num_values = (int)e.values.size();
+    }
+    return v;
+  }();
+  return entries;
+}
+
+int ipopt_option_count(void) { return (int)catalogue().size(); }
+
+bool ipopt_option_get(int i, ipopt_option_info *info) {
+  const std::vector<option_entry> &c = catalogue();
+  if (i < 0 || i >= (int)c.size()) {
+    return false;
+  }
+  *info = c[i].info;
+  return true;
+}
+
+bool ipopt_option_get_value(int i, int j, const char **value,
+                            const char **description) {
+  const std::vector<option_entry> &c = catalogue();
+  if (i < 0 || i >= (int)c.size() || j < 0 || j >= (int)c[i].values.size()) {
+    return false;
+  }
+  *value = c[i].values[j].first.c_str();
+  *description = c[i].values[j].second.c_str();
+  return true;
+}
@@ -0,0 +1,65 @@
+#include "ipopt_c_api.h"
+#include "ipopt_problem.h"
+
+#include "IpJournalist.hpp"
+
+#include <cstdarg>
+#include <cstdio>
+#include <cstring>
+#include <vector>
+
+using namespace Ipopt;
+
+// Exported from ipopt_cfunc.go.
+extern "C" void outputFunc(char *str, int n, uintptr_t user_data);
+
+namespace {
+
+// GoJournal forwards everything Ipopt prints to the io.Writer set with
+// Problem.SetOutput.
+class GoJournal : public Journal {
+public:
+  GoJournal(const std::string &name, uintptr_t user_data)
+      : Journal(name, J_ITERSUMMARY), user_data_(user_data) {}
+
+protected:
+  void PrintImpl(EJournalCategory, EJournalLevel, const char *str) override {
+    outputFunc(const_cast<char *>(str), (int)strlen(str), user_data_);
+  }
+
+  void PrintfImpl(EJournalCategory, EJournalLevel, const char *pformat,
+                  va_list ap) override {
+    va_list aq;
+    va_copy(aq, ap);
+    int n = vsnprintf(NULL, 0, pformat, aq);
+    va_end(aq);
+    if (n <= 0) {
+      return;
+    }
+    std::vector<char> buf(n + 1);
+    vsnprintf(buf.data(), buf.size(), pformat, ap);
+    outputFunc(buf.data(), n, user_data_);
+  }
+
+  void FlushBufferImpl() override {}
+
+private:
+  uintptr_t user_data_;
+};
+
+} // namespace
+
+// ipopt_problem_set_output replaces all journals of the problem by a console
+// journal writing to Go, or to stdout when user_data is 0. Ipopt sets the
+// level of the console journal from print_level before every solve.
+bool ipopt_problem_set_output(ipopt_problem_t *p, uintptr_t user_data) {
+  if (p->problem == NULL) {
+    return false;
+  }
+  SmartPtr<Journalist> jnlst = ipopt_problem_app(p)->Jnlst();
+  jnlst->DeleteAllJournals();
+  if (user_data == 0) {
+    return IsValid(jnlst->AddFileJournal("console", "stdout", J_ITERSUMMARY));
+  }
+  return jnlst->AddJournal(new GoJournal("console", user_data));
+}
@@ -0,0 +1,33 @@
+#ifndef GO_IPOPT_PROBLEM_H_
+#define GO_IPOPT_PROBLEM_H_
+
+#include "IpStdCInterface.h"
+
+struct _ipopt_problem_t {
+  IpoptProblem problem;
+};
+
+#ifdef __cplusplus
+#include "IpIpoptApplication.hpp"
+#include "IpoptConfig.h"
+
+// ipopt_problem_prefix mirrors the leading member of IpoptProblemInfo, which
+// is private to IpStdCInterface.cpp, to reach the application of a problem.
+// This relies on the layout of the vendored Ipopt 3.14.17, where app is the
+// first member; Ipopt 3.13 and older put it elsewhere. After updating
+// external/Ipopt, compare IpoptProblemInfo in IpStdCInterface.cpp with this
+// struct before changing the version check below.
+#if IPOPT_VERSION_MAJOR != 3 || IPOPT_VERSION_MINOR != 14 || IPOPT_VERSION_RELEASE != 17
+#error "ipopt_problem_prefix is only verified against Ipopt 3.14.17"
+#endif
+
+struct ipopt_problem_prefix {
+  Ipopt::SmartPtr<Ipopt::IpoptApplication> app;
+};
+
+inline Ipopt::IpoptApplication *ipopt_problem_app(ipopt_problem_t *p) {
+  return GetRawPtr(reinterpret_cast<ipopt_problem_prefix *>(p->problem)->app);
+}
+#endif
+
+#endif
@@ -0,0 +1,42 @@
+#include "ipopt_c_api.h"
+#include "ipopt_problem.h"
+
+#include "IpSolveStatistics.hpp"
+
+using namespace Ipopt;
+
+bool ipopt_problem_get_statistics(ipopt_problem_t *p,
+                                  ipopt_solve_statistics *stats) {
+  if (p->problem == NULL) {
+    return false;
+  }
+  SmartPtr<SolveStatistics> s = ipopt_problem_app(p)->Statistics();
+  if (!IsValid(s)) {
+    return false;
+  }
+
+  stats->iteration_count = s->IterationCount();
+  stats->total_cpu_time = s->TotalCpuTime();
+  stats->total_sys_time = s->TotalSysTime();
+  stats->total_wallclock_time = s->TotalWallclockTime();
+
+  Ipopt::Index obj, constr, grad, jac, hess;
+  s->NumberOfEvaluations(obj, constr, grad, jac, hess);
+  stats->num_obj_evals = obj;
+  stats->num_constr_evals = constr;
+  stats->num_obj_grad_evals = grad;
+  stats->num_constr_jac_evals = jac;
+  stats->num_hess_evals = hess;
+
+  s->Infeasibilities(stats->dual_inf, stats->constr_viol,
+                     stats->varbounds_viol, stats->complementarity,
+                     stats->kkt_error);
+  s->ScaledInfeasibilities(
+      stats->scaled_dual_inf, stats->scaled_constr_viol,
+      stats->scaled_varbounds_viol, stats->scaled_complementarity,
+      stats->scaled_kkt_error);
+
+  stats->final_objective = s->FinalObjective();
+  stats->final_scaled_objective = s->FinalScaledObjective();
+  return true;
+}
@@ -0,0 +1,119 @@
+package ipopt
+
+// #include "ipopt_c_api.h"
+import "C"
+import "time"
+
+// Stats summarizes a solve. The infeasibilities and objectives are those of
+// the final iterate, as reported by Ipopt's SolveStatistics.
+type Stats struct {
+	Iterations int
+
+	Objective       float64
+	ScaledObjective float64
+
+	DualInfeasibility   float64
+	ConstraintViolation float64
+	BoundViolation      float64
+	Complementarity     float64
+	NLPError            float64
+
+	ScaledDualInfeasibility   float64
+	ScaledConstraintViolation float64
+	ScaledBoundViolation      float64
+	ScaledComplementarity     float64
+	ScaledNLPError            float64
+
+	// WallTime, CPUTime and SysTime cover the whole solve, including
+	// callbacks.
+	WallTime time.Duration
+	CPUTime  time.Duration
+	SysTime  time.Duration
+
+	// Evaluations are Ipopt's own counts of function evaluations, which
+	// exclude structure queries.
+	Evaluations Evaluations
+
+	// Calls and time spent in each Go callback. Structure queries Ipopt makes
+	// during the solve count as calls of EvalJacG and EvalH; the check of
+	// undeclared structures before the first solve is not counted.
+	Eval         CallbackStats
+	EvalGrad     CallbackStats
+	EvalG        CallbackStats
+	EvalJacG     CallbackStats
+	EvalH        CallbackStats
+	Intermediate CallbackStats
+
+	// CallbackTime is the time spent in all Go callbacks, and IpoptTime the
+	// rest of WallTime.
+	CallbackTime time.Duration
+	IpoptTime    time.Duration
+}
+
+// Evaluations counts the function evaluations Ipopt requested.
+type Evaluations struct {
+	Objective   int
+	Constraints int
+	Gradient    int
+	Jacobian    int
+	Hessian     int
+}
+
+// CallbackStats counts the invocations of a callback.
+type CallbackStats struct {
+	Calls    int
+	Duration time.Duration
+}
+
+// track starts timing a callback; the returned function must be deferred.
+func (s *CallbackStats) track() func() {
+	start := time.Now()
+	return func() {
+		s.Calls++
+		s.Duration += time.Since(start)
+	}
+}
+
+// statistics combines the callback counters of the last solve with Ipopt's
+// statistics, when Ipopt got far enough to produce them.
+func (p *innerProblem) statistics() Stats {
+	s := p.cb.stats
+	s.Iterations = p.cb.iter
+	s.CallbackTime = s.Eval.Duration + s.EvalGrad.Duration + s.EvalG.Duration +
+		s.EvalJacG.Duration + s.EvalH.Duration + s.Intermediate.Duration
+
+	var cs C.ipopt_solve_statistics
+	if !C.ipopt_problem_get_statistics(p.problem, &cs) {
+		return s
+	}
+
+	s.Iterations = int(cs.iteration_count)
+	s.Objective = float64(cs.final_objective)
+	s.ScaledObjective = float64(cs.final_scaled_objective)
+	s.DualInfeasibility = float64(cs.dual_inf)
+	s.ConstraintViolation = float64(cs.constr_viol)
+	s.BoundViolation = float64(cs.varbounds_viol)
+	s.Complementarity = float64(cs.complementarity)
+	s.NLPError = float64(cs.kkt_error)
+	s.ScaledDualInfeasibility = float64(cs.scaled_dual_inf)
+	s.ScaledConstraintViolation = float64(cs.scaled_constr_viol)
+	s.ScaledBoundViolation = float64(cs.scaled_varbounds_viol)
+	s.ScaledComplementarity = float64(cs.scaled_complementarity)
+	s.ScaledNLPError = float64(cs.scaled_kkt_error)
+	s.WallTime = seconds(float64(cs.total_wallclock_time))
+	s.CPUTime = seconds(float64(cs.total_cpu_time))
+	s.SysTime = seconds(float64(cs.total_sys_time))
+	s.Evaluations = Evaluations{
+		Objective:   int(cs.num_obj_evals),
+		Constraints: int(cs.num_constr_evals),
+		Gradient:    int(cs.num_obj_grad_evals),
+		Jacobian:    int(cs.num_constr_jac_evals),
+		Hessian:     int(cs.num_hess_evals),
+	}
+	s.IpoptTime = s.WallTime - s.CallbackTime
+	return s
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
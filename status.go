@@ -0,0 +1,183 @@
+package ipopt
+
+// #include "ipopt_c_api.h"
+import "C"
+import "fmt"
+
+// Status is the return status of an Ipopt solve.
+type Status int
+
+const (
+	IPOPT_SOLVE_SUCCEEDED                    = Status(C.solve_succeeded)
+	IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL         = Status(C.solved_to_acceptable_level)
+	IPOPT_INFEASIBLE_PROBLEM_DETECTED        = Status(C.infeasible_problem_detected)
+	IPOPT_SEARCH_DIRECTION_BECOMES_TOO_SMALL = Status(C.search_direction_becomes_too_small)
+	IPOPT_DIVERGING_ITERATES                 = Status(C.diverging_iterates)
+	IPOPT_USER_REQUESTED_STOP                = Status(C.user_requested_stop)
+	IPOPT_FEASIBLE_POINT_FOUND               = Status(C.feasible_point_found)
+	IPOPT_MAXIMUM_ITERATIONS_EXCEEDED        = Status(C.maximum_iterations_exceeded)
+	IPOPT_RESTORATION_FAILED                 = Status(C.restoration_failed)
+	IPOPT_ERROR_IN_STEP_COMPUTATION          = Status(C.error_in_step_computation)
+	IPOPT_MAXIMUM_CPUTIME_EXCEEDED           = Status(C.maximum_cputime_exceeded)
+	IPOPT_MAXIMUM_WALLTIME_EXCEEDED          = Status(C.maximum_walltime_exceeded)
+	IPOPT_NOT_ENOUGH_DEGREES_OF_FREEDOM      = Status(C.not_enough_degrees_of_freedom)
+	IPOPT_INVALID_PROBLEM_DEFINITION         = Status(C.invalid_problem_definition)
+	IPOPT_INVALID_OPTION                     = Status(C.invalid_option)
+	IPOPT_INVALID_NUMBER_DETECTED            = Status(C.invalid_number_detected)
+	IPOPT_UNRECOVERABLE_EXCEPTION            = Status(C.unrecoverable_exception)
+	IPOPT_NON_IPOPT_EXCEPTION_THROWN         = Status(C.non_ipopt_exception_thrown)
+	IPOPT_INSUFFICIENT_MEMORY                = Status(C.insufficient_memory)
+	IPOPT_INTERNAL_ERROR                     = Status(C.internal_error)
+)
+
+// Sentinel errors for every status other than IPOPT_SOLVE_SUCCEEDED. They
+// can be matched with errors.Is; errors.As with a *StatusError gives access
+// to the Status itself.
+var (
+	ErrSolvedToAcceptableLevel        = &StatusError{IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL}
+	ErrInfeasibleProblemDetected      = &StatusError{IPOPT_INFEASIBLE_PROBLEM_DETECTED}
+	ErrSearchDirectionBecomesTooSmall = &StatusError{IPOPT_SEARCH_DIRECTION_BECOMES_TOO_SMALL}
+	ErrDivergingIterates              = &StatusError{IPOPT_DIVERGING_ITERATES}
+	ErrUserRequestedStop              = &StatusError{IPOPT_USER_REQUESTED_STOP}
+	ErrFeasiblePointFound             = &StatusError{IPOPT_FEASIBLE_POINT_FOUND}
+	ErrMaximumIterationsExceeded      = &StatusError{IPOPT_MAXIMUM_ITERATIONS_EXCEEDED}
+	ErrRestorationFailed              = &StatusError{IPOPT_RESTORATION_FAILED}
+	ErrErrorInStepComputation         = &StatusError{IPOPT_ERROR_IN_STEP_COMPUTATION}
+	ErrMaximumCpuTimeExceeded         = &StatusError{IPOPT_MAXIMUM_CPUTIME_EXCEEDED}
+	ErrMaximumWallTimeExceeded        = &StatusError{IPOPT_MAXIMUM_WALLTIME_EXCEEDED}
+	ErrNotEnoughDegreesOfFreedom      = &StatusError{IPOPT_NOT_ENOUGH_DEGREES_OF_FREEDOM}
+	ErrInvalidProblemDefinition       = &StatusError{IPOPT_INVALID_PROBLEM_DEFINITION}
+	ErrInvalidOption                  = &StatusError{IPOPT_INVALID_OPTION}
+	ErrInvalidNumberDetected          = &StatusError{IPOPT_INVALID_NUMBER_DETECTED}
+	ErrUnrecoverableException         = &StatusError{IPOPT_UNRECOVERABLE_EXCEPTION}
+	ErrNonIpoptExceptionThrown        = &StatusError{IPOPT_NON_IPOPT_EXCEPTION_THROWN}
+	ErrInsufficientMemory             = &StatusError{IPOPT_INSUFFICIENT_MEMORY}
+	ErrInternalError                  = &StatusError{IPOPT_INTERNAL_ERROR}
+)
+
+var statusErrors = map[Status]*StatusError{
+	IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL:         ErrSolvedToAcceptableLevel,
+	IPOPT_INFEASIBLE_PROBLEM_DETECTED:        ErrInfeasibleProblemDetected,
+	IPOPT_SEARCH_DIRECTION_BECOMES_TOO_SMALL: ErrSearchDirectionBecomesTooSmall,
+	IPOPT_DIVERGING_ITERATES:                 ErrDivergingIterates,
+	IPOPT_USER_REQUESTED_STOP:                ErrUserRequestedStop,
+	IPOPT_FEASIBLE_POINT_FOUND:               ErrFeasiblePointFound,
+	IPOPT_MAXIMUM_ITERATIONS_EXCEEDED:        ErrMaximumIterationsExceeded,
+	IPOPT_RESTORATION_FAILED:                 ErrRestorationFailed,
+	IPOPT_ERROR_IN_STEP_COMPUTATION:          ErrErrorInStepComputation,
+	IPOPT_MAXIMUM_CPUTIME_EXCEEDED:           ErrMaximumCpuTimeExceeded,
+	IPOPT_MAXIMUM_WALLTIME_EXCEEDED:          ErrMaximumWallTimeExceeded,
+	IPOPT_NOT_ENOUGH_DEGREES_OF_FREEDOM:      ErrNotEnoughDegreesOfFreedom,
+	IPOPT_INVALID_PROBLEM_DEFINITION:         ErrInvalidProblemDefinition,
+	IPOPT_INVALID_OPTION:                     ErrInvalidOption,
+	IPOPT_INVALID_NUMBER_DETECTED:            ErrInvalidNumberDetected,
+	IPOPT_UNRECOVERABLE_EXCEPTION:            ErrUnrecoverableException,
+	IPOPT_NON_IPOPT_EXCEPTION_THROWN:         ErrNonIpoptExceptionThrown,
+	IPOPT_INSUFFICIENT_MEMORY:                ErrInsufficientMemory,
+	IPOPT_INTERNAL_ERROR:                     ErrInternalError,
+}
+
+func (s Status) String() string {
+	switch s {
+	case IPOPT_SOLVE_SUCCEEDED:
+		return "Solve Succeeded"
+	case IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL:
+		return "Solved To Acceptable Level"
+	case IPOPT_INFEASIBLE_PROBLEM_DETECTED:
+		return "Infeasible Problem Detected"
+	case IPOPT_SEARCH_DIRECTION_BECOMES_TOO_SMALL:
+		return "Search Direction Becomes Too Small"
+	case IPOPT_DIVERGING_ITERATES:
+		return "Diverging Iterates"
+	case IPOPT_USER_REQUESTED_STOP:
+		return "User Requested Stop"
+	case IPOPT_FEASIBLE_POINT_FOUND:
+		return "Feasible Point Found"
+	case IPOPT_MAXIMUM_ITERATIONS_EXCEEDED:
+		return "Maximum Iterations Exceeded"
+	case IPOPT_RESTORATION_FAILED:
+		return "Restoration Failed"
+	case IPOPT_ERROR_IN_STEP_COMPUTATION:
+		return "Error In Step Computation"
+	case IPOPT_MAXIMUM_CPUTIME_EXCEEDED:
+		return "Maximum CpuTime Exceeded"
+	case IPOPT_MAXIMUM_WALLTIME_EXCEEDED:
+		return "Maximum WallTime Exceeded"
+	case IPOPT_NOT_ENOUGH_DEGREES_OF_FREEDOM:
+		return "Not Enough Degrees Of Freedom"
+	case IPOPT_INVALID_PROBLEM_DEFINITION:
+		return "Invalid Problem Definition"
+	case IPOPT_INVALID_OPTION:
+		return "Invalid Option"
+	case IPOPT_INVALID_NUMBER_DETECTED:
+		return "Invalid Number Detected"
+	case IPOPT_UNRECOVERABLE_EXCEPTION:
+		return "Unrecoverable Exception"
+	case IPOPT_NON_IPOPT_EXCEPTION_THROWN:
+		return "NonIpopt Exception Thrown"
+	case IPOPT_INSUFFICIENT_MEMORY:
+		return "Insufficient Memory"
+	case IPOPT_INTERNAL_ERROR:
+		return "Internal Error"
+	}
+	return fmt.Sprintf("Unknown Status (%d)", int(s))
+}
+
+// Succeeded reports whether Ipopt converged to a point satisfying the
+// requested tolerances, or found a feasible point of a square problem.
+func (s Status) Succeeded() bool {
+	return s == IPOPT_SOLVE_SUCCEEDED || s == IPOPT_FEASIBLE_POINT_FOUND
+}
+
+// Acceptable reports whether Ipopt stopped at a point satisfying only the
+// acceptable_* tolerances.
+func (s Status) Acceptable() bool {
+	return s == IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL
+}
+
+// Infeasible reports whether Ipopt converged to a point of local
+// infeasibility.
+func (s Status) Infeasible() bool {
+	return s == IPOPT_INFEASIBLE_PROBLEM_DETECTED
+}
+
+// LimitReached reports whether the solve stopped on an iteration or time
+// limit.
+func (s Status) LimitReached() bool {
+	switch s {
+	case IPOPT_MAXIMUM_ITERATIONS_EXCEEDED, IPOPT_MAXIMUM_CPUTIME_EXCEEDED, IPOPT_MAXIMUM_WALLTIME_EXCEEDED:
+		return true
+	}
+	return false
+}
+
+// FatalError reports whether the solve could not run properly, because of
+// an invalid problem or option, an exception or lack of memory.
+func (s Status) FatalError() bool {
+	return s <= IPOPT_NOT_ENOUGH_DEGREES_OF_FREEDOM
+}
+
+// Err returns the sentinel error for s, or nil for IPOPT_SOLVE_SUCCEEDED.
+func (s Status) Err() error {
+	if s == IPOPT_SOLVE_SUCCEEDED {
+		return nil
+	}
+	if err, ok := statusErrors[s]; ok {
+		return err
+	}
+	return &StatusError{s}
+}
+
+// StatusError is the error returned for an unsuccessful solve.
+type StatusError struct {
+	Status Status
+}
+
+func (e *StatusError) Error() string {
+	return e.Status.String()
+}
+
+func (e *StatusError) Is(target error) bool {
+	t, ok := target.(*StatusError)
+	return ok && t.Status == e.Status
+}
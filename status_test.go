@@ -0,0 +1,45 @@
+package ipopt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStatusErr(t *testing.T) {
+	if err := IPOPT_SOLVE_SUCCEEDED.Err(); err != nil {
+		t.Fatalf("Solve Succeeded: got %v, want nil", err)
+	}
+
+	err := IPOPT_INFEASIBLE_PROBLEM_DETECTED.Err()
+	if !errors.Is(err, ErrInfeasibleProblemDetected) {
+		t.Fatalf("errors.Is(%v, ErrInfeasibleProblemDetected) = false", err)
+	}
+	if errors.Is(err, ErrMaximumIterationsExceeded) {
+		t.Fatalf("errors.Is(%v, ErrMaximumIterationsExceeded) = true", err)
+	}
+
+	var se *StatusError
+	if !errors.As(err, &se) || !se.Status.Infeasible() {
+		t.Fatalf("errors.As(%v) did not yield an infeasible status", err)
+	}
+
+	unknown := Status(42).Err()
+	if unknown.Error() != "Unknown Status (42)" {
+		t.Fatalf("unknown status message = %q", unknown.Error())
+	}
+	if !errors.Is(unknown, &StatusError{Status(42)}) {
+		t.Fatalf("errors.Is does not match an equal unknown status")
+	}
+}
+
+func TestStatusClassification(t *testing.T) {
+	if !IPOPT_MAXIMUM_WALLTIME_EXCEEDED.LimitReached() {
+		t.Error("Maximum WallTime Exceeded should be a limit")
+	}
+	if !IPOPT_INVALID_OPTION.FatalError() || IPOPT_RESTORATION_FAILED.FatalError() {
+		t.Error("unexpected FatalError classification")
+	}
+	if !IPOPT_FEASIBLE_POINT_FOUND.Succeeded() || IPOPT_SOLVED_TO_ACCEPTABLE_LEVEL.Succeeded() {
+		t.Error("unexpected Succeeded classification")
+	}
+}
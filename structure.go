@@ -0,0 +1,134 @@
+package ipopt
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// StructureError reports an invalid entry of the Jacobian or Hessian
+// sparsity structure.
+type StructureError struct {
+	Matrix string
+	Index  int
+	Row    int32
+	Col    int32
+	Reason string
+}
+
+func (e *StructureError) Error() string {
+	return fmt.Sprintf("%s structure entry %d (row %d, col %d): %s", e.Matrix, e.Index, e.Row, e.Col, e.Reason)
+}
+
+// structureSize checks a declared sparsity structure against the number
+// of nonzeros given in ProblemOptions, where zero means not given.
+func structureSize(name string, structure [2][]int32, declared int) (int, error) {
+	rows, cols := structure[0], structure[1]
+	if len(rows) != len(cols) {
+		return 0, fmt.Errorf("%s structure has %d rows but %d cols", name, len(rows), len(cols))
+	}
+	if declared != 0 && declared != len(rows) {
+		return 0, fmt.Errorf("%s structure has %d entries, declared %d", name, len(rows), declared)
+	}
+	return len(rows), nil
+}
+
+// checkStructure validates the indices of a structure with nRows rows and
+// nCols columns. Entries equal to -1 were not filled by the callback. For
+// the Hessian only the lower triangle may be given.
+func checkStructure(name string, structure [2][]int32, nRows int, nCols int, lower bool) error {
+	rows, cols := structure[0], structure[1]
+	seen := make(map[[2]int32]int, len(rows))
+
+	for k := range rows {
+		row, col := rows[k], cols[k]
+		entryErr := func(format string, args ...any) error {
+			return &StructureError{Matrix: name, Index: k, Row: row, Col: col, Reason: fmt.Sprintf(format, args...)}
+		}
+
+		switch {
+		case row == -1 && col == -1:
+			return entryErr("not filled, the callback set fewer than %d entries", len(rows))
+		case row < 0 || int(row) >= nRows:
+			return entryErr("row out of range [0, %d)", nRows)
+		case col < 0 || int(col) >= nCols:
+			return entryErr("col out of range [0, %d)", nCols)
+		case lower && row < col:
+			return entryErr("above the diagonal, only the lower triangle may be given")
+		}
+
+		key := [2]int32{row, col}
+		if first, ok := seen[key]; ok {
+			return entryErr("duplicates entry %d", first)
+		}
+		seen[key] = k
+	}
+	return nil
+}
+
+// checkCallbackStructures asks the callbacks for the structures that were
+// not declared in ProblemOptions and validates them. It runs once, before
+// the first solve.
+func (p *innerProblem) checkCallbackStructures() error {
+	if p.structureChecked {
+		return nil
+	}
+
+	cb := p.cb
+	if cb.jacStructure[0] == nil && p.nnzj > 0 && cb.evalJacG != nil {
+		jac := newStructure(p.nnzj)
+		err := queryStructure("EvalJacG", func() error {
+			return cb.evalJacG(nil, false, p.m, jac, nil)
+		})
+		if err != nil {
+			return err
+		}
+		if err := checkStructure("jacobian", jac, p.m, p.n, false); err != nil {
+			return err
+		}
+	}
+
+	if cb.hessStructure[0] == nil && p.nnzh > 0 && cb.evalH != nil {
+		hess := newStructure(p.nnzh)
+		err := queryStructure("EvalH", func() error {
+			return cb.evalH(nil, false, 0, p.m, nil, false, hess, nil)
+		})
+		if err != nil {
+			return err
+		}
+		if err := checkStructure("hessian", hess, p.n, p.n, true); err != nil {
+			return err
+		}
+	}
+
+	p.structureChecked = true
+	return nil
+}
+
+// newStructure returns index slices marked as not filled.
+func newStructure(nnz int) [2][]int32 {
+	s := [2][]int32{make([]int32, nnz), make([]int32, nnz)}
+	for i := 0; i < nnz; i++ {
+		s[0][i] = -1
+		s[1][i] = -1
+	}
+	return s
+}
+
+// queryStructure runs a structure query of a callback, reporting its
+// failures like a solve would.
+func queryStructure(callback string, query func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Callback: callback, Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	if err := query(); err != nil {
+		if errors.Is(err, ErrDomain) {
+			return fmt.Errorf("%s failed to return the sparsity structure", callback)
+		}
+		return &CallbackError{Callback: callback, Err: err}
+	}
+	return nil
+}
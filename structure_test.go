@@ -0,0 +1,126 @@
+package ipopt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckStructure(t *testing.T) {
+	tests := []struct {
+		name      string
+		structure [2][]int32
+		lower     bool
+		index     int
+	}{
+		{"valid", [2][]int32{{0, 1, 1}, {0, 0, 1}}, true, -1},
+		{"row out of range", [2][]int32{{0, 2}, {0, 0}}, false, 1},
+		{"col out of range", [2][]int32{{0, 1}, {0, 3}}, false, 1},
+		{"upper triangle", [2][]int32{{0, 0}, {0, 1}}, true, 1},
+		{"duplicate", [2][]int32{{1, 0, 1}, {0, 0, 0}}, false, 2},
+		{"not filled", [2][]int32{{0, -1}, {0, -1}}, false, 1},
+	}
+
+	for _, tt := range tests {
+		err := checkStructure("hessian", tt.structure, 2, 2, tt.lower)
+		if tt.index < 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+
+		var se *StructureError
+		if !errors.As(err, &se) || se.Index != tt.index {
+			t.Errorf("%s: got %v, want error for entry %d", tt.name, err, tt.index)
+		}
+	}
+}
+
+func TestCallbackStructureError(t *testing.T) {
+	p := &MyProblem{}
+
+	jacQueries := 0
+	badJac := hs071Options(p)
+	badJac.EvalJacG = func(x []float64, newX bool, m int, jac [2][]int32, values []float64) bool {
+		ok := p.evalJacG(x, newX, m, jac, values)
+		if values == nil {
+			jacQueries++
+			jac[1][5] = 4 // столбец вне диапазона
+		}
+		return ok
+	}
+
+	hessQueries := 0
+	badHess := hs071Options(p)
+	badHess.EvalH = func(x []float64, newX bool, objFactor float64, m int, lambda []float64, newLambda bool, hess [2][]int32, values []float64) bool {
+		ok := p.evalH(x, newX, objFactor, m, lambda, newLambda, hess, values)
+		if values == nil {
+			hessQueries++
+			hess[0][1], hess[1][1] = 0, 1 // элемент над диагональю
+		}
+		return ok
+	}
+
+	tests := []struct {
+		name    string
+		opt     ProblemOptions
+		matrix  string
+		index   int
+		queries *int
+	}{
+		{"jacobian col out of range", badJac, "jacobian", 5, &jacQueries},
+		{"hessian upper triangle", badHess, "hessian", 1, &hessQueries},
+	}
+	for _, tt := range tests {
+		problem, err := NewProblem(tt.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		problem.AddIntOption("print_level", 0)
+
+		_, err = problem.Optimize([]float64{1, 5, 5, 1})
+		var se *StructureError
+		if !errors.As(err, &se) || se.Matrix != tt.matrix || se.Index != tt.index {
+			t.Errorf("%s: got %v, want error for %s entry %d", tt.name, err, tt.matrix, tt.index)
+		}
+		// Ошибка найдена до запуска Ipopt: структура запрошена один раз
+		if *tt.queries != 1 {
+			t.Errorf("%s: structure queried %d times, want 1", tt.name, *tt.queries)
+		}
+		problem.Close()
+	}
+}
+
+func TestCallbackStructureCheckedOnce(t *testing.T) {
+	p := &MyProblem{}
+	opt := hs071Options(p)
+
+	queries := 0
+	opt.EvalJacG = func(x []float64, newX bool, m int, jac [2][]int32, values []float64) bool {
+		if values == nil {
+			queries++
+		}
+		return p.evalJacG(x, newX, m, jac, values)
+	}
+
+	problem, err := NewProblem(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer problem.Close()
+	problem.AddIntOption("print_level", 0)
+
+	if _, err := problem.Optimize([]float64{1, 5, 5, 1}); err != nil {
+		t.Fatal(err)
+	}
+	first := queries
+	queries = 0
+	if _, err := problem.Optimize([]float64{1, 5, 5, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Первый запуск добавляет к запросам Ipopt одну проверку структуры
+	if first != queries+1 {
+		t.Errorf("structure queried %d times in the first solve and %d in the second, want one more in the first", first, queries)
+	}
+}
@@ -0,0 +1,139 @@
+package ipopt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// warmStartDefaults are applied by WarmStart for options the user has not
+// set. Ipopt's defaults push the starting point and the multipliers far
+// enough into the interior to lose most of the benefit of a warm start.
+var warmStartDefaults = map[string]float64{
+	"warm_start_bound_push":       1e-9,
+	"warm_start_bound_frac":       1e-9,
+	"warm_start_slack_bound_push": 1e-9,
+	"warm_start_slack_bound_frac": 1e-9,
+	"warm_start_mult_bound_push":  1e-9,
+}
+
+// WarmStart solves the problem starting from the solution and multipliers of
+// prev, which is left unmodified. See WarmStartContext.
+func (p *Problem) WarmStart(prev *Result) (*Result, error) {
+	return p.WarmStartContext(context.Background(), prev)
+}
+
+// WarmStartContext is like OptimizeContext but starts from the primal and
+// dual solution in prev, typically the result of solving a slightly
+// different instance of the same model. For this solve it enables
+// warm_start_init_point and sets small warm_start_*_push and _frac values
+// unless they were set on the problem; afterwards these options are back to
+// what they were. Lowering mu_init, e.g. to 1e-6, often helps further.
+func (p *Problem) WarmStartContext(ctx context.Context, prev *Result) (*Result, error) {
+	if prev == nil {
+		return nil, errors.New("warm start requires a previous result")
+	}
+
+	defer runtime.KeepAlive(p)
+	p.inner.mu.Lock()
+	defer p.inner.mu.Unlock()
+
+	if p.inner.closed() {
+		return nil, ErrProblemClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	n, m := p.inner.n, p.inner.m
+	for _, s := range []struct {
+		name string
+		v    []float64
+		want int
+	}{
+		{"X", prev.X, n},
+		{"MultG", prev.MultG, m},
+		{"MultXL", prev.MultXL, n},
+		{"MultXU", prev.MultXU, n},
+	} {
+		if len(s.v) != s.want {
+			return nil, fmt.Errorf("previous result %s has %d elements, want %d", s.name, len(s.v), s.want)
+		}
+	}
+	if err := p.inner.checkCallbackStructures(); err != nil {
+		return nil, err
+	}
+
+	restore, err := p.inner.applyWarmStart()
+	defer restore()
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{
+		X:      append([]float64(nil), prev.X...),
+		G:      make([]float64, m),
+		MultG:  append(make([]float64, 0, m), prev.MultG...),
+		MultXL: append([]float64(nil), prev.MultXL...),
+		MultXU: append([]float64(nil), prev.MultXU...),
+	}
+	return p.optimize(ctx, res)
+}
+
+// applyWarmStart enables warm_start_init_point and sets the warm start
+// defaults the user has not set. The returned function puts every option it
+// changed back to its previous value, or to Ipopt's default.
+func (p *innerProblem) applyWarmStart() (func(), error) {
+	type saved struct {
+		name  string
+		value any
+		ok    bool
+	}
+	var changed []saved
+	restore := func() {
+		for _, s := range changed {
+			value := s.value
+			if !s.ok {
+				info, _ := LookupOption(s.name)
+				value = info.Default
+			}
+			p.setOption(s.name, value)
+			if !s.ok {
+				delete(p.options, s.name)
+			}
+		}
+	}
+
+	set := func(name string, value any) error {
+		prev, ok := p.options[name]
+		changed = append(changed, saved{name, prev, ok})
+		return p.setOption(name, value)
+	}
+
+	if err := set("warm_start_init_point", "yes"); err != nil {
+		return restore, err
+	}
+	for name, v := range warmStartDefaults {
+		if _, ok := p.options[name]; ok {
+			continue
+		}
+		if err := set(name, v); err != nil {
+			return restore, err
+		}
+	}
+	return restore, nil
+}
+
+// setOption sets an option from a value of the type recorded in options.
+func (p *innerProblem) setOption(name string, value any) error {
+	switch v := value.(type) {
+	case float64:
+		return p.addNumOption(name, v)
+	case int:
+		return p.addIntOption(name, v)
+	case string:
+		return p.addStrOption(name, v)
+	}
+	return &OptionError{Option: name, Value: value, Err: ErrOptionType}
+}